@@ -0,0 +1,141 @@
+package mesh
+
+import "sort"
+
+// Edge is a pair of points connected by an element edge, with the smaller
+// PointID first.
+type Edge [2]PointID
+
+// Adjacency is the point graph of a mesh in compressed sparse row form. The
+// neighbors of point i are Indices[Offsets[i]:Offsets[i+1]] in increasing
+// order, and EdgeIds holds the index in Edges of each of those connections.
+// Edges is the list of unique edges sorted by their first and then second
+// point.
+type Adjacency struct {
+	Offsets []int
+	Indices []PointID
+	EdgeIds []int
+	Edges   []Edge
+}
+
+// Adjacency builds the point graph of the mesh from the element edges. It uses
+// much less memory than Point.Neighbors for large meshes.
+func (s *SU2) Adjacency() *Adjacency {
+	nPoints := len(s.Points)
+
+	// Bucket every element edge by its smaller point. Duplicates are removed
+	// when each bucket is sorted.
+	start := make([]int, nPoints+1)
+	for _, elem := range s.Elements {
+		for _, edge := range elem.Type.Edges() {
+			a, b := elem.VertexIds[edge[0]], elem.VertexIds[edge[1]]
+			if b < a {
+				a = b
+			}
+			start[a+1]++
+		}
+	}
+	for i := 0; i < nPoints; i++ {
+		start[i+1] += start[i]
+	}
+	upper := make([]PointID, start[nPoints])
+	fill := make([]int, nPoints)
+	copy(fill, start)
+	for _, elem := range s.Elements {
+		for _, edge := range elem.Type.Edges() {
+			a, b := elem.VertexIds[edge[0]], elem.VertexIds[edge[1]]
+			if b < a {
+				a, b = b, a
+			}
+			upper[fill[a]] = b
+			fill[a]++
+		}
+	}
+
+	// Sort each bucket and compact its unique entries to the front, keeping
+	// their number in fill.
+	nEdges := 0
+	for i := 0; i < nPoints; i++ {
+		bucket := upper[start[i]:start[i+1]]
+		insertionSort(bucket)
+		n := 0
+		for j, b := range bucket {
+			if j > 0 && b == bucket[n-1] {
+				continue
+			}
+			bucket[n] = b
+			n++
+		}
+		fill[i] = n
+		nEdges += n
+	}
+
+	adj := &Adjacency{
+		Offsets: make([]int, nPoints+1),
+		Edges:   make([]Edge, 0, nEdges),
+	}
+	degree := make([]int, nPoints)
+	for i := 0; i < nPoints; i++ {
+		for _, b := range upper[start[i] : start[i]+fill[i]] {
+			adj.Edges = append(adj.Edges, Edge{PointID(i), b})
+			degree[i]++
+			degree[b]++
+		}
+	}
+
+	for i := 0; i < nPoints; i++ {
+		adj.Offsets[i+1] = adj.Offsets[i] + degree[i]
+	}
+	adj.Indices = make([]PointID, adj.Offsets[nPoints])
+	adj.EdgeIds = make([]int, adj.Offsets[nPoints])
+	copy(degree, adj.Offsets[:nPoints])
+	pos := degree
+	// The edges are sorted, so the neighbors of each point are added in
+	// increasing order: first the smaller points, then the larger ones.
+	for i, edge := range adj.Edges {
+		a, b := edge[0], edge[1]
+		adj.Indices[pos[a]] = b
+		adj.EdgeIds[pos[a]] = i
+		pos[a]++
+		adj.Indices[pos[b]] = a
+		adj.EdgeIds[pos[b]] = i
+		pos[b]++
+	}
+	return adj
+}
+
+// NumPoints returns the number of points in the graph.
+func (a *Adjacency) NumPoints() int {
+	return len(a.Offsets) - 1
+}
+
+// Neighbors returns the neighbors of the point in increasing order. The
+// returned slice must not be modified.
+func (a *Adjacency) Neighbors(p PointID) []PointID {
+	return a.Indices[a.Offsets[p]:a.Offsets[p+1]]
+}
+
+// Degree returns the number of neighbors of the point.
+func (a *Adjacency) Degree(p PointID) int {
+	return a.Offsets[p+1] - a.Offsets[p]
+}
+
+// Edge returns the index in Edges of the edge between points p and q, and
+// false if they are not connected.
+func (a *Adjacency) Edge(p, q PointID) (int, bool) {
+	neighbors := a.Neighbors(p)
+	i := sort.Search(len(neighbors), func(i int) bool { return neighbors[i] >= q })
+	if i == len(neighbors) || neighbors[i] != q {
+		return -1, false
+	}
+	return a.EdgeIds[a.Offsets[p]+i], true
+}
+
+// insertionSort sorts the short list of ids in place.
+func insertionSort(ids []PointID) {
+	for i := 1; i < len(ids); i++ {
+		for j := i; j > 0 && ids[j] < ids[j-1]; j-- {
+			ids[j], ids[j-1] = ids[j-1], ids[j]
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package mesh
+
+import (
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestAdjacency(t *testing.T) {
+	for _, file := range []string{hybrid2D, hybrid3D} {
+		s := readString(t, file)
+		adj := s.Adjacency()
+		nEdges := 0
+		for i, point := range s.Points {
+			neighbors := adj.Neighbors(PointID(i))
+			if len(neighbors) != len(point.OrderedNeighbors) {
+				t.Fatalf("point %d: neighbor mismatch. Expected %v, found %v", i, len(point.OrderedNeighbors), len(neighbors))
+			}
+			for j, id := range neighbors {
+				if id != point.OrderedNeighbors[j].Id {
+					t.Errorf("point %d: neighbor mismatch. Expected %v, found %v", i, point.OrderedNeighbors[j].Id, id)
+				}
+				e, ok := adj.Edge(PointID(i), id)
+				if !ok {
+					t.Errorf("no edge between %d and %d", i, id)
+					continue
+				}
+				edge := adj.Edges[e]
+				if (edge != Edge{PointID(i), id}) && (edge != Edge{id, PointID(i)}) {
+					t.Errorf("bad edge %v between %d and %d", edge, i, id)
+				}
+			}
+			nEdges += len(neighbors)
+		}
+		if nEdges != 2*len(adj.Edges) {
+			t.Errorf("Edge count mismatch. Expected %v, found %v", nEdges/2, len(adj.Edges))
+		}
+		if _, ok := adj.Edge(0, 2); ok {
+			t.Errorf("found edge between unconnected points")
+		}
+	}
+
+	s := readString(t, hybrid2D)
+	s.SkipNeighbors = true
+	if err := s.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Points[0].Neighbors != nil || s.Points[0].OrderedNeighbors != nil {
+		t.Errorf("neighbors built with SkipNeighbors")
+	}
+}
+
+// structuredQuads returns a uniform mesh of quadrilaterals with ni by nj
+// points.
+func structuredQuads(ni, nj int) *SU2 {
+	s := &SU2{Dim: 2}
+	for j := 0; j < nj; j++ {
+		for i := 0; i < ni; i++ {
+			s.Points = append(s.Points, &Point{
+				Id:       PointID(len(s.Points)),
+				Location: []float64{float64(i), float64(j)},
+			})
+		}
+	}
+	for j := 0; j < nj-1; j++ {
+		for i := 0; i < ni-1; i++ {
+			p := PointID(i + j*ni)
+			s.Elements = append(s.Elements, &Element{
+				Id:        ElementID(len(s.Elements)),
+				Type:      Quadrilateral,
+				VertexIds: []PointID{p, p + 1, p + 1 + PointID(ni), p + PointID(ni)},
+			})
+		}
+	}
+	return s
+}
+
+// initializeMaps builds the neighbor maps by inserting every element edge into
+// per-point maps, which is how initialize worked before Adjacency. It is kept
+// for the benchmarks.
+func (s *SU2) initializeMaps() {
+	for _, point := range s.Points {
+		point.Neighbors = make(map[PointID]*Point)
+	}
+	for _, elem := range s.Elements {
+		for _, edge := range elem.Type.Edges() {
+			a := elem.VertexIds[edge[0]]
+			b := elem.VertexIds[edge[1]]
+			s.Points[a].Neighbors[b] = s.Points[b]
+			s.Points[b].Neighbors[a] = s.Points[a]
+		}
+	}
+	for _, point := range s.Points {
+		ids := make([]int, 0, len(point.Neighbors))
+		for id := range point.Neighbors {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+		point.OrderedNeighbors = make([]*Point, len(ids))
+		for i, id := range ids {
+			point.OrderedNeighbors[i] = point.Neighbors[PointID(id)]
+		}
+	}
+}
+
+func flatPlate(b *testing.B) *SU2 {
+	f, err := os.Open("mesh_flatplate_turb_137x97.su2")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	s := &SU2{SkipNeighbors: true}
+	_, err = s.ReadFrom(f)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return s
+}
+
+func benchmarkInitializeMaps(b *testing.B, s *SU2) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.initializeMaps()
+	}
+}
+
+func benchmarkInitialize(b *testing.B, s *SU2) {
+	s.SkipNeighbors = false
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.initialize(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkAdjacency(b *testing.B, s *SU2) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Adjacency()
+	}
+}
+
+func BenchmarkInitializeMapsFlatPlate(b *testing.B) { benchmarkInitializeMaps(b, flatPlate(b)) }
+func BenchmarkInitializeFlatPlate(b *testing.B)     { benchmarkInitialize(b, flatPlate(b)) }
+func BenchmarkAdjacencyFlatPlate(b *testing.B)      { benchmarkAdjacency(b, flatPlate(b)) }
+
+func BenchmarkInitializeMapsLarge(b *testing.B) {
+	benchmarkInitializeMaps(b, structuredQuads(1000, 1000))
+}
+func BenchmarkInitializeLarge(b *testing.B) { benchmarkInitialize(b, structuredQuads(1000, 1000)) }
+func BenchmarkAdjacencyLarge(b *testing.B)  { benchmarkAdjacency(b, structuredQuads(1000, 1000)) }
@@ -0,0 +1,494 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Airfoil is the surface of a 2D airfoil. The points start at the trailing
+// edge, go over the upper surface to the leading edge and back along the lower
+// surface to the trailing edge.
+type Airfoil struct {
+	Name   string
+	Points [][]float64
+}
+
+// ReadAirfoil reads airfoil coordinates in the Selig or Lednicer format. Selig
+// files list the points in the order of Airfoil. Lednicer files start with the
+// number of points on the upper and lower surfaces, followed by both surfaces
+// from the leading edge to the trailing edge.
+func ReadAirfoil(r io.Reader) (*Airfoil, error) {
+	airfoil := &Airfoil{}
+	var rows [][]float64
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		str := strings.TrimSpace(scanner.Text())
+		if str == "" {
+			continue
+		}
+		strs := strings.Fields(str)
+		var row []float64
+		if len(strs) == 2 {
+			for _, s := range strs {
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					break
+				}
+				row = append(row, v)
+			}
+		}
+		if len(row) != 2 {
+			if len(rows) == 0 && airfoil.Name == "" {
+				airfoil.Name = str
+				continue
+			}
+			return nil, fmt.Errorf("airfoil: line %d: bad coordinates %q", line, str)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("airfoil: no coordinates")
+	}
+
+	nu, nl := rows[0][0], rows[0][1]
+	if nu >= 2 && nl >= 2 && nu == math.Trunc(nu) && nl == math.Trunc(nl) {
+		// Lednicer format
+		rows = rows[1:]
+		if len(rows) != int(nu)+int(nl) {
+			return nil, fmt.Errorf("airfoil: %d points for %d upper and %d lower", len(rows), int(nu), int(nl))
+		}
+		upper, lower := rows[:int(nu)], rows[int(nu):]
+		for i := len(upper) - 1; i >= 0; i-- {
+			airfoil.Points = append(airfoil.Points, upper[i])
+		}
+		if distance(upper[0], lower[0]) == 0 {
+			lower = lower[1:]
+		}
+		airfoil.Points = append(airfoil.Points, lower...)
+	} else {
+		airfoil.Points = rows
+	}
+	if len(airfoil.Points) < 4 {
+		return nil, fmt.Errorf("airfoil: only %d points", len(airfoil.Points))
+	}
+	return airfoil, nil
+}
+
+// GridTopology is the topology of a structured airfoil grid.
+type GridTopology int
+
+const (
+	CGrid GridTopology = iota
+	OGrid
+)
+
+// Smoothing is the method used to place the interior points of a structured
+// grid.
+type Smoothing int
+
+const (
+	Algebraic Smoothing = iota // Curves from the wall normals to the farfield
+	Elliptic                   // Algebraic grid smoothed by Winslow's equations
+)
+
+// AirfoilGrid describes a structured quadrilateral mesh around an airfoil. The
+// markers are airfoil on the surface and farfield on the outer boundary,
+// including the outflow boundary of a C-grid.
+type AirfoilGrid struct {
+	Topology GridTopology
+
+	// NSurface is the number of points on the airfoil surface, counting the
+	// trailing edge at both ends. NNormal is the number of points from the
+	// surface to the farfield, and NWake the number of points along the wake
+	// cut of a C-grid, not counting the trailing edge.
+	NSurface, NNormal, NWake int
+
+	// WallSpacing is the height of the first cell at the wall.
+	WallSpacing float64
+	// FarfieldRadius is the distance to the farfield in chords. The farfield
+	// of an O-grid is a circle around the mid-chord. The farfield of a C-grid
+	// is a half circle around the trailing edge closed by straight lines to
+	// the outflow, which is FarfieldRadius chords downstream of the trailing
+	// edge.
+	FarfieldRadius float64
+
+	Smoothing Smoothing
+	// Iterations is the maximum number of elliptic smoothing iterations. If
+	// it is zero, 500 iterations are used.
+	Iterations int
+}
+
+// Mesh returns the grid around the airfoil. Blunt trailing edges are closed
+// at their midpoint by thinning the airfoil toward it. The points on the wake
+// cut of a C-grid and on the trailing edge line of an O-grid are merged.
+func (g AirfoilGrid) Mesh(a *Airfoil) (*SU2, error) {
+	if g.NSurface < 5 || g.NNormal < 3 || (g.Topology == CGrid && g.NWake < 1) {
+		return nil, fmt.Errorf("airfoil grid: too few points")
+	}
+	if g.WallSpacing <= 0 {
+		return nil, fmt.Errorf("airfoil grid: bad wall spacing %v", g.WallSpacing)
+	}
+	if g.FarfieldRadius <= 1 {
+		return nil, fmt.Errorf("airfoil grid: farfield radius %v is not larger than the chord", g.FarfieldRadius)
+	}
+	surface, frac, lead := resampleAirfoil(a.Points, g.NSurface)
+	te := surface[0]
+	chord := distance(te, surface[lead])
+	if chord == 0 {
+		return nil, errors.New("airfoil grid: zero chord")
+	}
+	radius := g.FarfieldRadius * chord
+
+	// Angle of the farfield point matching each surface point, with the
+	// trailing edge at the start and the leading edge halfway.
+	angle := func(i int) float64 {
+		if i <= lead {
+			return math.Pi / 2 * frac[i] / frac[lead]
+		}
+		return math.Pi/2 + math.Pi/2*(frac[i]-frac[lead])/(1-frac[lead])
+	}
+
+	// The inner and outer boundaries along i
+	var inner, outer [][]float64
+	switch g.Topology {
+	case CGrid:
+		first := distance(surface[0], surface[1])
+		sp := Spacing{Stretching: Geometric, FirstCell: first}
+		if first >= radius/float64(g.NWake) {
+			sp = Spacing{}
+		}
+		wake, err := sp.Distribute(g.NWake+1, radius)
+		if err != nil {
+			return nil, fmt.Errorf("airfoil grid: wake: %v", err)
+		}
+		// The wake is clustered toward the trailing edge, but the lines from
+		// it spread out uniformly along the farfield.
+		for k := g.NWake; k > 0; k-- {
+			inner = append(inner, []float64{te[0] + wake[k], te[1]})
+			outer = append(outer, []float64{te[0] + radius*float64(k)/float64(g.NWake), te[1] + radius})
+		}
+		for i, x := range surface {
+			inner = append(inner, x)
+			theta := math.Pi/2 + angle(i)
+			outer = append(outer, []float64{te[0] + radius*math.Cos(theta), te[1] + radius*math.Sin(theta)})
+		}
+		for k := 1; k <= g.NWake; k++ {
+			inner = append(inner, []float64{te[0] + wake[k], te[1]})
+			outer = append(outer, []float64{te[0] + radius*float64(k)/float64(g.NWake), te[1] - radius})
+		}
+	case OGrid:
+		center := average([][]float64{te, surface[lead]})
+		for i, x := range surface {
+			inner = append(inner, x)
+			theta := 2 * angle(i)
+			outer = append(outer, []float64{center[0] + radius*math.Cos(theta), center[1] + radius*math.Sin(theta)})
+		}
+		// Both ends of the trailing edge line must be identical to be merged
+		outer[len(outer)-1] = outer[0]
+	default:
+		return nil, fmt.Errorf("airfoil grid: unknown topology %d", g.Topology)
+	}
+	periodic := g.Topology == OGrid
+
+	x, err := g.algebraic(inner, outer, periodic)
+	if err != nil {
+		return nil, err
+	}
+	if g.Smoothing == Elliptic {
+		iter := g.Iterations
+		if iter == 0 {
+			iter = 500
+		}
+		ellipticSmooth(x, iter, 1e-12*chord)
+
+		// Smoothing changes the spacing at the wall, so the points are
+		// placed again along the smoothed lines.
+		for i := range x {
+			if x[i], err = g.normalPoints(x[i]); err != nil {
+				return nil, err
+			}
+		}
+		if periodic {
+			x[len(x)-1] = x[0]
+		}
+	}
+	if i, j := foldedCell(x); i != -1 {
+		return nil, fmt.Errorf("airfoil grid: folded cell (%d, %d)", i, j)
+	}
+
+	ni, nj := len(x), len(x[0])
+	b := &block{ni: ni, nj: nj, nk: 1}
+	for j := 0; j < nj; j++ {
+		for i := 0; i < ni; i++ {
+			b.x = append(b.x, x[i][j])
+		}
+	}
+	patches := []Patch{{Face: JMax, Tag: "farfield"}}
+	if g.Topology == CGrid {
+		patches = append(patches,
+			Patch{Face: JMin, Tag: "airfoil", Range: [2][2]int{{g.NWake, g.NWake + g.NSurface - 1}}},
+			Patch{Face: IMin, Tag: "farfield"},
+			Patch{Face: IMax, Tag: "farfield"},
+		)
+	} else {
+		patches = append(patches, Patch{Face: JMin, Tag: "airfoil"})
+	}
+	return structuredMesh([]*block{b}, patches, 0)
+}
+
+// resampleAirfoil returns n points on the airfoil surface clustered toward the
+// leading and trailing edges, their fraction of the surface length, and the
+// index of the leading edge. A blunt trailing edge is closed at its midpoint.
+func resampleAirfoil(points [][]float64, n int) (surface [][]float64, frac []float64, lead int) {
+	var unique [][]float64
+	for i, x := range points {
+		if i == 0 || distance(x, points[i-1]) != 0 {
+			unique = append(unique, x)
+		}
+	}
+	points = unique
+	last := len(points) - 1
+	te := average([][]float64{points[0], points[last]})
+	le := 0
+	for i := range points {
+		if distance(points[i], te) > distance(points[le], te) {
+			le = i
+		}
+	}
+	if distance(points[0], points[last]) != 0 {
+		// Close the trailing edge by thinning each surface linearly along the
+		// chord.
+		chord := sub(te, points[le])
+		closed := make([][]float64, len(points))
+		for i, x := range points {
+			end := points[0]
+			if i > le {
+				end = points[last]
+			}
+			f := dot(sub(x, points[le]), chord) / dot(chord, chord)
+			closed[i] = sub(x, scale(sub(end, te), f))
+		}
+		closed[0], closed[last] = te, te
+		points = closed
+	}
+	s := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		s[i] = s[i-1] + distance(points[i-1], points[i])
+	}
+	total := s[len(s)-1]
+
+	// Tangents for cubic Hermite interpolation in arc length
+	tangent := make([][]float64, len(points))
+	for i := range points {
+		lo, hi := i-1, i+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi == len(points) {
+			hi = i
+		}
+		tangent[i] = scale(sub(points[hi], points[lo]), 1/(s[hi]-s[lo]))
+	}
+	at := func(t float64) []float64 {
+		k := 0
+		for k < len(s)-2 && s[k+1] < t {
+			k++
+		}
+		h := s[k+1] - s[k]
+		u := (t - s[k]) / h
+		h00 := 2*u*u*u - 3*u*u + 1
+		h10 := u*u*u - 2*u*u + u
+		h01 := -2*u*u*u + 3*u*u
+		h11 := u*u*u - u*u
+		x := make([]float64, 2)
+		for d := range x {
+			x[d] = h00*points[k][d] + h10*h*tangent[k][d] + h01*points[k+1][d] + h11*h*tangent[k+1][d]
+		}
+		return x
+	}
+
+	lead = n / 2
+	surface = make([][]float64, n)
+	frac = make([]float64, n)
+	for i := range surface {
+		var t float64
+		if i <= lead {
+			t = s[le] * (1 - math.Cos(math.Pi*float64(i)/float64(lead))) / 2
+		} else {
+			u := float64(i-lead) / float64(n-1-lead)
+			t = s[le] + (total-s[le])*(1-math.Cos(math.Pi*u))/2
+		}
+		surface[i] = at(t)
+		frac[i] = t / total
+	}
+	surface[0] = append([]float64(nil), points[0]...)
+	surface[lead] = append([]float64(nil), points[le]...)
+	surface[n-1] = surface[0]
+	return surface, frac, lead
+}
+
+// algebraic returns the grid points x[i][j] on cubic curves that leave the
+// inner boundary along its normal and end at the outer boundary. The points
+// along each curve are clustered toward the wall with the first cell height
+// WallSpacing.
+func (g AirfoilGrid) algebraic(inner, outer [][]float64, periodic bool) ([][][]float64, error) {
+	const samples = 1000
+	ni := len(inner)
+	x := make([][][]float64, ni)
+	for i := range inner {
+		lo, hi := i-1, i+1
+		if periodic && (i == 0 || i == ni-1) {
+			lo, hi = ni-2, 1
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		if hi == ni {
+			hi = i
+		}
+		d := sub(inner[hi], inner[lo])
+		normal := []float64{d[1], -d[0]}
+		scale(normal, 1/norm(normal))
+
+		// The curves follow the normal for a few surface cells, so curves
+		// from concave corners bend away before they cross.
+		p0, p1 := inner[i], outer[i]
+		t1 := sub(p1, p0)
+		t0 := scale(normal, math.Min(norm(t1), norm(d)/2))
+		curve := make([][]float64, samples+1)
+		for k := range curve {
+			// Sample densely near the wall
+			u := math.Pow(float64(k)/samples, 3)
+			h00 := 2*u*u*u - 3*u*u + 1
+			h10 := u*u*u - 2*u*u + u
+			h01 := -2*u*u*u + 3*u*u
+			h11 := u*u*u - u*u
+			curve[k] = []float64{
+				h00*p0[0] + h10*t0[0] + h01*p1[0] + h11*t1[0],
+				h00*p0[1] + h10*t0[1] + h01*p1[1] + h11*t1[1],
+			}
+		}
+		var err error
+		x[i], err = g.normalPoints(curve)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if periodic {
+		x[ni-1] = x[0]
+	}
+	return x, nil
+}
+
+// normalPoints returns NNormal points along the polyline from the wall, with
+// the first cell height WallSpacing.
+func (g AirfoilGrid) normalPoints(curve [][]float64) ([][]float64, error) {
+	length := make([]float64, len(curve))
+	for k := 1; k < len(curve); k++ {
+		length[k] = length[k-1] + distance(curve[k-1], curve[k])
+	}
+	last := len(curve) - 1
+	sp := Spacing{Stretching: Geometric, FirstCell: g.WallSpacing}
+	dist, err := sp.Distribute(g.NNormal, length[last])
+	if err != nil {
+		return nil, fmt.Errorf("airfoil grid: normal spacing: %v", err)
+	}
+	x := make([][]float64, g.NNormal)
+	k := 0
+	for j, v := range dist {
+		for k < last-1 && length[k+1] < v {
+			k++
+		}
+		u := (v - length[k]) / (length[k+1] - length[k])
+		x[j] = []float64{
+			curve[k][0] + u*(curve[k+1][0]-curve[k][0]),
+			curve[k][1] + u*(curve[k+1][1]-curve[k][1]),
+		}
+	}
+	x[0] = append([]float64(nil), curve[0]...)
+	x[g.NNormal-1] = append([]float64(nil), curve[last]...)
+	return x, nil
+}
+
+// ellipticSmooth moves the interior points of the grid x[i][j] toward the
+// solution of Winslow's equations by Gauss-Seidel iteration. The first and last
+// lines in both directions are held fixed. The control functions are found from
+// the initial grid next to the inner and outer boundaries and interpolated
+// between them, following Thomas and Middlecoff, so the clustering of the
+// initial grid is kept.
+func ellipticSmooth(x [][][]float64, iter int, tol float64) {
+	ni, nj := len(x), len(x[0])
+	control := func(a, b, c []float64) float64 {
+		d1 := []float64{(c[0] - a[0]) / 2, (c[1] - a[1]) / 2}
+		d2 := []float64{c[0] - 2*b[0] + a[0], c[1] - 2*b[1] + a[1]}
+		return -dot(d1, d2) / dot(d1, d1)
+	}
+	phi := make([][2]float64, ni)
+	psi := make([][2]float64, ni)
+	for i := 1; i < ni-1; i++ {
+		for n, j := range [2]int{1, nj - 2} {
+			phi[i][n] = control(x[i-1][j], x[i][j], x[i+1][j])
+			psi[i][n] = control(x[i][j-1], x[i][j], x[i][j+1])
+		}
+	}
+
+	for it := 0; it < iter; it++ {
+		var change float64
+		for i := 1; i < ni-1; i++ {
+			ip, in := i-1, i+1
+			for j := 1; j < nj-1; j++ {
+				w := float64(j) / float64(nj-1)
+				p := (1-w)*phi[i][0] + w*phi[i][1]
+				q := (1-w)*psi[i][0] + w*psi[i][1]
+				var xi, eta [2]float64
+				for d := 0; d < 2; d++ {
+					xi[d] = (x[in][j][d] - x[ip][j][d]) / 2
+					eta[d] = (x[i][j+1][d] - x[i][j-1][d]) / 2
+				}
+				alpha := eta[0]*eta[0] + eta[1]*eta[1]
+				beta := xi[0]*eta[0] + xi[1]*eta[1]
+				gamma := xi[0]*xi[0] + xi[1]*xi[1]
+				for d := 0; d < 2; d++ {
+					cross := (x[in][j+1][d] - x[in][j-1][d] - x[ip][j+1][d] + x[ip][j-1][d]) / 4
+					v := (alpha*(x[in][j][d]+x[ip][j][d]+p*xi[d]) +
+						gamma*(x[i][j+1][d]+x[i][j-1][d]+q*eta[d]) -
+						2*beta*cross) / (2 * (alpha + gamma))
+					change = math.Max(change, math.Abs(v-x[i][j][d]))
+					x[i][j][d] = v
+				}
+			}
+		}
+		if change < tol {
+			return
+		}
+	}
+}
+
+// foldedCell returns the indices of a cell of the grid x[i][j] with the
+// opposite orientation to the first cell, or -1 if there is none.
+func foldedCell(x [][][]float64) (int, int) {
+	var sign float64
+	for i := 0; i < len(x)-1; i++ {
+		for j := 0; j < len(x[i])-1; j++ {
+			a, b, c, d := x[i][j], x[i+1][j], x[i+1][j+1], x[i][j+1]
+			area := (c[0]-a[0])*(d[1]-b[1]) - (c[1]-a[1])*(d[0]-b[0])
+			if sign == 0 {
+				sign = math.Copysign(1, area)
+			}
+			if area*sign <= 0 {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
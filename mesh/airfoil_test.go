@@ -0,0 +1,121 @@
+package mesh
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// naca0012 returns the coordinates of a NACA 0012 airfoil with a blunt
+// trailing edge in the Selig format.
+func naca0012(n int) string {
+	var buf bytes.Buffer
+	buf.WriteString("NACA 0012\n")
+	for i := 0; i <= 2*n; i++ {
+		x := (1 + math.Cos(math.Pi*float64(i)/float64(n))) / 2
+		y := 0.6 * (0.2969*math.Sqrt(x) - 0.126*x - 0.3516*x*x + 0.2843*x*x*x - 0.1015*x*x*x*x)
+		if i > n {
+			y = -y
+		}
+		fmt.Fprintf(&buf, "%.6f %.6f\n", x, y)
+	}
+	return buf.String()
+}
+
+var lednicer = `LEDNICER TEST
+3.       3.
+
+0.0 0.0
+0.5 0.1
+1.0 0.0
+
+0.0 0.0
+0.5 -0.1
+1.0 0.0
+`
+
+func TestReadAirfoil(t *testing.T) {
+	a, err := ReadAirfoil(strings.NewReader(lednicer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]float64{{1, 0}, {0.5, 0.1}, {0, 0}, {0.5, -0.1}, {1, 0}}
+	if a.Name != "LEDNICER TEST" || len(a.Points) != len(want) {
+		t.Fatalf("Airfoil mismatch. Found %v", a)
+	}
+	for i := range want {
+		if !closeTo(a.Points[i], want[i], 0) {
+			t.Errorf("Point %d mismatch. Expected %v, found %v", i, want[i], a.Points[i])
+		}
+	}
+
+	a, err = ReadAirfoil(strings.NewReader(naca0012(40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "NACA 0012" || len(a.Points) != 81 {
+		t.Errorf("Airfoil mismatch. Found %s with %d points", a.Name, len(a.Points))
+	}
+}
+
+func TestAirfoilGrid(t *testing.T) {
+	a, err := ReadAirfoil(strings.NewReader(naca0012(40)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, smoothing := range []Smoothing{Algebraic, Elliptic} {
+		for _, g := range []AirfoilGrid{
+			{Topology: CGrid, NSurface: 65, NNormal: 33, NWake: 16},
+			{Topology: OGrid, NSurface: 65, NNormal: 33},
+		} {
+			g.WallSpacing = 1e-4
+			g.FarfieldRadius = 20
+			g.Smoothing = smoothing
+			s, err := g.Mesh(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ni := g.NSurface + 2*g.NWake
+			points := ni*g.NNormal - g.NWake - 1
+			farfield := ni - 1 + 2*(g.NNormal-1)
+			if g.Topology == OGrid {
+				points = (ni - 1) * g.NNormal
+				farfield = ni - 1
+			}
+			if len(s.Points) != points || len(s.Elements) != (ni-1)*(g.NNormal-1) {
+				t.Errorf("Size mismatch. Found %d points and %d elements", len(s.Points), len(s.Elements))
+			}
+			for _, elem := range s.Elements {
+				if s.Volume(elem) <= 0 {
+					t.Fatalf("inverted element %v", elem)
+				}
+			}
+			for _, test := range []struct {
+				tag   string
+				count int
+			}{{"airfoil", g.NSurface - 1}, {"farfield", farfield}} {
+				marker := s.Marker(test.tag)
+				if marker == nil || len(marker.Elements) != test.count {
+					t.Errorf("Marker %s mismatch: %v", test.tag, marker)
+				}
+			}
+
+			// The first point off the wall at mid-chord on the upper surface
+			for _, elem := range s.Marker("airfoil").Elements {
+				x := s.Points[elem.VertexIds[0]].Location
+				if x[0] > 0.5 || x[1] < 0 {
+					continue
+				}
+				for _, p := range s.Points[elem.VertexIds[0]].OrderedNeighbors {
+					y := p.Location
+					if d := distance(x, y); math.Abs(y[0]-x[0]) < 0.01 && math.Abs(d-g.WallSpacing) > 0.2*g.WallSpacing {
+						t.Errorf("Wall spacing mismatch. Expected %v, found %v", g.WallSpacing, d)
+					}
+				}
+				break
+			}
+		}
+	}
+}
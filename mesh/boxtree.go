@@ -0,0 +1,174 @@
+package mesh
+
+import (
+	"math"
+	"sort"
+)
+
+// boxLeafSize is the largest number of items in a leaf of a boxTree.
+const boxLeafSize = 4
+
+// boxTree is a bounding volume hierarchy over a set of axis aligned boxes,
+// used to find the items near a point without checking every item.
+type boxTree struct {
+	nodes []boxNode
+	items []int // Item indices, in the order of the leaves
+}
+
+// boxNode is a node of a boxTree. Leaves have left == -1 and hold
+// items[start:end]. Internal nodes have children left and right.
+type boxNode struct {
+	lo, hi      []float64
+	left, right int
+	start, end  int
+}
+
+// newBoxTree builds the tree over the boxes lo[i], hi[i]. The boxes are split
+// recursively at the median center along the longest side of the bounds of
+// their centers.
+func newBoxTree(lo, hi [][]float64) *boxTree {
+	t := &boxTree{items: make([]int, len(lo))}
+	for i := range t.items {
+		t.items[i] = i
+	}
+	if len(lo) > 0 {
+		t.build(lo, hi, 0, len(lo))
+	}
+	return t
+}
+
+// build adds the node over items[start:end] and returns its index.
+func (t *boxTree) build(lo, hi [][]float64, start, end int) int {
+	dim := len(lo[0])
+	node := boxNode{
+		lo:    append([]float64(nil), lo[t.items[start]]...),
+		hi:    append([]float64(nil), hi[t.items[start]]...),
+		left:  -1,
+		right: -1,
+		start: start,
+		end:   end,
+	}
+	cmin := make([]float64, dim)
+	cmax := make([]float64, dim)
+	for d := range cmin {
+		cmin[d], cmax[d] = math.Inf(1), math.Inf(-1)
+	}
+	for _, item := range t.items[start:end] {
+		for d := 0; d < dim; d++ {
+			node.lo[d] = math.Min(node.lo[d], lo[item][d])
+			node.hi[d] = math.Max(node.hi[d], hi[item][d])
+			c := lo[item][d] + hi[item][d]
+			cmin[d] = math.Min(cmin[d], c)
+			cmax[d] = math.Max(cmax[d], c)
+		}
+	}
+	index := len(t.nodes)
+	t.nodes = append(t.nodes, node)
+	if end-start <= boxLeafSize {
+		return index
+	}
+	axis := 0
+	for d := range cmin {
+		if cmax[d]-cmin[d] > cmax[axis]-cmin[axis] {
+			axis = d
+		}
+	}
+	items := t.items[start:end]
+	sort.Slice(items, func(i, j int) bool {
+		return lo[items[i]][axis]+hi[items[i]][axis] < lo[items[j]][axis]+hi[items[j]][axis]
+	})
+	mid := (start + end) / 2
+	left := t.build(lo, hi, start, mid)
+	right := t.build(lo, hi, mid, end)
+	t.nodes[index].left, t.nodes[index].right = left, right
+	return index
+}
+
+// boxDistance returns the distance from x to the closest point of the box.
+func boxDistance(x, lo, hi []float64) float64 {
+	var d2 float64
+	for i, v := range x {
+		var d float64
+		if v < lo[i] {
+			d = lo[i] - v
+		} else if v > hi[i] {
+			d = v - hi[i]
+		}
+		d2 += d * d
+	}
+	return math.Sqrt(d2)
+}
+
+// nearest returns the item with the smallest distance to x, given by dist,
+// and that distance. Boxes farther than the best distance so far are skipped,
+// so dist must be at least the distance from x to the item's box. It returns
+// -1 and +Inf for an empty tree.
+func (t *boxTree) nearest(x []float64, dist func(item int) float64) (int, float64) {
+	best, bestDist := -1, math.Inf(1)
+	if len(t.nodes) == 0 {
+		return best, bestDist
+	}
+	stack := []int{0}
+	for len(stack) > 0 {
+		n := &t.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if boxDistance(x, n.lo, n.hi) >= bestDist {
+			continue
+		}
+		if n.left == -1 {
+			for _, item := range t.items[n.start:n.end] {
+				if d := dist(item); d < bestDist {
+					best, bestDist = item, d
+				}
+			}
+			continue
+		}
+		// Visit the closer child first.
+		l, r := &t.nodes[n.left], &t.nodes[n.right]
+		if boxDistance(x, l.lo, l.hi) < boxDistance(x, r.lo, r.hi) {
+			stack = append(stack, n.right, n.left)
+		} else {
+			stack = append(stack, n.left, n.right)
+		}
+	}
+	return best, bestDist
+}
+
+// find calls visit with the items whose boxes contain x, within tol, until
+// visit returns true. It returns whether visit returned true.
+func (t *boxTree) find(x []float64, tol float64, visit func(item int) bool) bool {
+	if len(t.nodes) == 0 {
+		return false
+	}
+	stack := []int{0}
+	for len(stack) > 0 {
+		n := &t.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if boxDistance(x, n.lo, n.hi) > tol {
+			continue
+		}
+		if n.left == -1 {
+			for _, item := range t.items[n.start:n.end] {
+				if visit(item) {
+					return true
+				}
+			}
+			continue
+		}
+		stack = append(stack, n.right, n.left)
+	}
+	return false
+}
+
+// bounds returns the corners of the bounding box of the locations.
+func bounds(x [][]float64) (lo, hi []float64) {
+	lo = append([]float64(nil), x[0]...)
+	hi = append([]float64(nil), x[0]...)
+	for _, v := range x[1:] {
+		for d := range v {
+			lo[d] = math.Min(lo[d], v[d])
+			hi[d] = math.Max(hi[d], v[d])
+		}
+	}
+	return lo, hi
+}
@@ -0,0 +1,218 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+)
+
+// Curve is an ordered chain of the line elements of a 2D marker.
+type Curve struct {
+	// Points are the points in order along the curve. The first point of a
+	// closed curve is not repeated at the end.
+	Points []PointID
+	// Closed is true if the curve is a loop, like the surface of an airfoil.
+	Closed bool
+	// ArcLength is the distance along the curve from the first point to each
+	// point.
+	ArcLength []float64
+	// XC is the x coordinate of each point relative to the smallest x on the
+	// curve, divided by the chord, which is the extent of the curve in x.
+	XC []float64
+	// Corners are the indices in Points where the curve turns by more than the
+	// corner angle, such as a sharp trailing edge. The ends of an open curve
+	// are not corners.
+	Corners []int
+}
+
+// MarkerCurves chains the line elements of a 2D marker into ordered curves,
+// one for each connected piece of the marker. Corners are points where the
+// curve turns by more than cornerAngle degrees, and zero means 30 degrees.
+// The curves follow the direction of the marker elements where they agree.
+// Closed curves start at their sharpest corner, such as the trailing edge of
+// an airfoil, or else at the point with the largest x. Open curves start at
+// the end that the elements point away from, and come before the closed
+// curves.
+func (s *SU2) MarkerCurves(tag string, cornerAngle float64) ([]*Curve, error) {
+	if s.Dim != 2 {
+		return nil, fmt.Errorf("curves: %dD mesh", s.Dim)
+	}
+	marker := s.Marker(tag)
+	if marker == nil {
+		return nil, fmt.Errorf("curves: no marker %q", tag)
+	}
+	if cornerAngle == 0 {
+		cornerAngle = 30
+	}
+	// The elements at each point, at most two
+	links := make(map[PointID][]int)
+	for i, elem := range marker.Elements {
+		if elem.Type != Line {
+			return nil, fmt.Errorf("curves: marker %s element %d is a %v", tag, i, elem.Type)
+		}
+		for _, id := range elem.VertexIds {
+			links[id] = append(links[id], i)
+			if len(links[id]) > 2 {
+				return nil, fmt.Errorf("curves: marker %s branches at point %d", tag, id)
+			}
+		}
+	}
+
+	used := make([]bool, len(marker.Elements))
+	// walk follows the elements from the point through the element until it
+	// reaches an end or comes back to start, and returns the points in order.
+	walk := func(start PointID, elem int) []PointID {
+		points := []PointID{start}
+		p := start
+		for {
+			used[elem] = true
+			v := marker.Elements[elem].VertexIds
+			next := v[0]
+			if next == p {
+				next = v[1]
+			}
+			if next == start {
+				return points
+			}
+			points = append(points, next)
+			p = next
+			elem = -1
+			for _, e := range links[p] {
+				if !used[e] {
+					elem = e
+				}
+			}
+			if elem == -1 {
+				return points
+			}
+		}
+	}
+
+	var curves []*Curve
+	// Open curves start at the points with a single element.
+	for i, elem := range marker.Elements {
+		if used[i] {
+			continue
+		}
+		var start PointID = -1
+		var first int
+		for _, id := range elem.VertexIds {
+			if len(links[id]) == 1 {
+				start, first = id, i
+			}
+		}
+		if start == -1 {
+			continue
+		}
+		points := walk(start, first)
+		c := &Curve{Points: points}
+		if !s.forward(marker, points) {
+			reversePoints(points)
+		}
+		curves = append(curves, c)
+	}
+	// The rest of the elements are loops.
+	for i, elem := range marker.Elements {
+		if used[i] {
+			continue
+		}
+		// Walk along the direction of the element, then follow the majority
+		// including the segment that closes the loop.
+		points := walk(elem.VertexIds[0], i)
+		if !s.forward(marker, append(points[:len(points):len(points)], points[0])) {
+			reversePoints(points)
+		}
+		curves = append(curves, &Curve{Points: points, Closed: true})
+	}
+
+	for _, c := range curves {
+		s.findCorners(c, cornerAngle)
+		if c.Closed {
+			start := 0
+			if len(c.Corners) > 0 {
+				sharpest := -1.0
+				for _, i := range c.Corners {
+					if turn := s.turn(c, i); turn > sharpest {
+						start, sharpest = i, turn
+					}
+				}
+			} else {
+				for i, id := range c.Points {
+					if s.Points[id].Location[0] > s.Points[c.Points[start]].Location[0] {
+						start = i
+					}
+				}
+			}
+			c.Points = append(c.Points[start:], c.Points[:start]...)
+			s.findCorners(c, cornerAngle)
+		}
+		s.measure(c)
+	}
+	return curves, nil
+}
+
+// forward returns true if more of the marker elements along the chain of
+// points point forward than backward.
+func (s *SU2) forward(marker *Marker, points []PointID) bool {
+	next := make(map[PointID]PointID, len(marker.Elements))
+	for _, elem := range marker.Elements {
+		next[elem.VertexIds[0]] = elem.VertexIds[1]
+	}
+	count := 0
+	for i := 0; i+1 < len(points); i++ {
+		if n, ok := next[points[i]]; ok && n == points[i+1] {
+			count++
+		} else {
+			count--
+		}
+	}
+	return count >= 0
+}
+
+func reversePoints(p []PointID) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
+
+// turn returns the angle in degrees between the segments before and after
+// point i of the curve, or zero at the ends of an open curve.
+func (s *SU2) turn(c *Curve, i int) float64 {
+	n := len(c.Points)
+	if !c.Closed && (i == 0 || i == n-1) || n < 3 {
+		return 0
+	}
+	x := s.Points[c.Points[i]].Location
+	a := sub(x, s.Points[c.Points[(i+n-1)%n]].Location)
+	b := sub(s.Points[c.Points[(i+1)%n]].Location, x)
+	cos := dot(a, b) / (norm(a) * norm(b))
+	return math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+}
+
+// findCorners sets the corners of the curve.
+func (s *SU2) findCorners(c *Curve, cornerAngle float64) {
+	c.Corners = nil
+	for i := range c.Points {
+		if s.turn(c, i) > cornerAngle {
+			c.Corners = append(c.Corners, i)
+		}
+	}
+}
+
+// measure sets the arc length and the chord-normalized x of the curve.
+func (s *SU2) measure(c *Curve) {
+	c.ArcLength = make([]float64, len(c.Points))
+	c.XC = make([]float64, len(c.Points))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, id := range c.Points {
+		x := s.Points[id].Location
+		if i > 0 {
+			c.ArcLength[i] = c.ArcLength[i-1] + distance(x, s.Points[c.Points[i-1]].Location)
+		}
+		lo, hi = math.Min(lo, x[0]), math.Max(hi, x[0])
+	}
+	for i, id := range c.Points {
+		if hi > lo {
+			c.XC[i] = (s.Points[id].Location[0] - lo) / (hi - lo)
+		}
+	}
+}
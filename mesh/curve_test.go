@@ -0,0 +1,119 @@
+package mesh
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMarkerCurves(t *testing.T) {
+	s := readString(t, hybrid2D)
+	curves, err := s.MarkerCurves("upper", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curves) != 1 {
+		t.Fatalf("%d curves, expected 1", len(curves))
+	}
+	c := curves[0]
+	want := &Curve{
+		Points:    []PointID{5, 4, 3},
+		ArcLength: []float64{0, 0.5, 1},
+		XC:        []float64{1, 0.5, 0},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("curve mismatch. Expected %+v, found %+v", want, c)
+	}
+
+	// A sharp airfoil from points around the surface, with the marker elements
+	// shuffled and running clockwise from the trailing edge.
+	const n = 40
+	s = &SU2{Dim: 2}
+	for i := 0; i < 2*n; i++ {
+		theta := 2 * math.Pi * float64(i) / (2 * n)
+		x := 0.5 + 0.5*math.Cos(theta)
+		y := -5 * 0.12 * (0.2969*math.Sqrt(x) - 0.1260*x - 0.3516*x*x + 0.2843*x*x*x - 0.1036*x*x*x*x)
+		if i > n {
+			y = -y
+		}
+		s.Points = append(s.Points, &Point{Id: PointID(i), Location: []float64{x, y}})
+	}
+	marker := &Marker{Tag: "airfoil"}
+	for _, i := range rand.New(rand.NewSource(1)).Perm(2 * n) {
+		marker.Elements = append(marker.Elements, Element{
+			Id:        -1,
+			Type:      Line,
+			VertexIds: []PointID{PointID(i), PointID((i + 1) % (2 * n))},
+		})
+	}
+	// A separate flap
+	for i := 0; i < 3; i++ {
+		s.Points = append(s.Points, &Point{Id: PointID(2*n + i), Location: []float64{1.1 + 0.1*float64(i), 0}})
+	}
+	marker.Elements = append(marker.Elements,
+		Element{Id: -1, Type: Line, VertexIds: []PointID{2*n + 2, 2*n + 1}},
+		Element{Id: -1, Type: Line, VertexIds: []PointID{2*n + 1, 2 * n}},
+	)
+	s.Markers = []*Marker{marker}
+
+	curves, err = s.MarkerCurves("airfoil", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curves) != 2 {
+		t.Fatalf("%d curves, expected 2", len(curves))
+	}
+	flap, airfoil := curves[0], curves[1]
+	if flap.Closed || !reflect.DeepEqual(flap.Points, []PointID{2*n + 2, 2*n + 1, 2 * n}) || len(flap.Corners) != 0 {
+		t.Errorf("flap mismatch: %+v", flap)
+	}
+	if !airfoil.Closed || len(airfoil.Points) != 2*n {
+		t.Fatalf("airfoil mismatch: %+v", airfoil)
+	}
+	for i, id := range airfoil.Points {
+		if id != PointID(i) {
+			t.Fatalf("airfoil points out of order: %v", airfoil.Points)
+		}
+	}
+	if !reflect.DeepEqual(airfoil.Corners, []int{0}) {
+		t.Errorf("corner mismatch. Expected the trailing edge, found %v", airfoil.Corners)
+	}
+	if airfoil.XC[0] != 1 || airfoil.XC[n] != 0 {
+		t.Errorf("x/c mismatch: %v %v", airfoil.XC[0], airfoil.XC[n])
+	}
+	for i := 1; i < len(airfoil.ArcLength); i++ {
+		if airfoil.ArcLength[i] <= airfoil.ArcLength[i-1] {
+			t.Errorf("arc length not increasing at %d", i)
+		}
+	}
+
+	marker.Elements = append(marker.Elements, Element{Id: -1, Type: Line, VertexIds: []PointID{0, n}})
+	if _, err := s.MarkerCurves("airfoil", 0); err == nil {
+		t.Errorf("no error for branching marker")
+	}
+
+	// A circle with the first element reversed follows the other elements.
+	s = &SU2{Dim: 2}
+	marker = &Marker{Tag: "circle"}
+	for i := 0; i < 8; i++ {
+		theta := 2 * math.Pi * float64(i) / 8
+		s.Points = append(s.Points, &Point{Id: PointID(i), Location: []float64{math.Cos(theta), math.Sin(theta)}})
+		v := []PointID{PointID(i), PointID((i + 1) % 8)}
+		if i == 0 {
+			v[0], v[1] = v[1], v[0]
+		}
+		marker.Elements = append(marker.Elements, Element{Id: -1, Type: Line, VertexIds: v})
+	}
+	s.Markers = []*Marker{marker}
+	curves, err = s.MarkerCurves("circle", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(curves) != 1 {
+		t.Fatalf("%d curves, expected 1", len(curves))
+	}
+	if !reflect.DeepEqual(curves[0].Points, []PointID{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("circle mismatch: %+v", curves[0])
+	}
+}
@@ -0,0 +1,528 @@
+package mesh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DeformMethod is the algorithm used to move the volume points of a mesh.
+type DeformMethod int
+
+const (
+	// RBF interpolates the boundary displacements with a linear fit plus
+	// Wendland C2 radial basis functions.
+	RBF DeformMethod = iota
+	// Spring treats every edge as a spring with stiffness inversely
+	// proportional to its length, and solves for the equilibrium with the
+	// conjugate gradient method.
+	Spring
+)
+
+// Deformation describes how to move the volume points of a mesh to follow
+// displacements of its boundary.
+type Deformation struct {
+	Method DeformMethod
+
+	// Radius is the support radius of the RBF. Zero means the largest
+	// distance between boundary points, so every point moves.
+	Radius float64
+	// Tolerance enables greedy reduction of the RBF control points. Points
+	// are added one at a time where the boundary displacement error is
+	// largest until it is below Tolerance times the largest displacement.
+	// Zero uses every boundary point.
+	Tolerance float64
+
+	// Iterations is the largest number of conjugate gradient iterations for
+	// Spring. Zero means 10 times the number of points.
+	Iterations int
+	// Residual is the relative residual at which Spring stops. Zero means
+	// 1e-10.
+	Residual float64
+}
+
+// DeformReport describes the deformed mesh.
+type DeformReport struct {
+	// MinVolume is the smallest element volume after deformation, which is
+	// not positive if an element is inverted.
+	MinVolume float64
+	// Inverted are the elements with volumes that are not positive.
+	Inverted []ElementID
+	// ControlPoints is the number of RBF control points.
+	ControlPoints int
+	// Iterations is the number of Spring iterations.
+	Iterations int
+}
+
+func (r *DeformReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "min volume: %g\n", r.MinVolume)
+	fmt.Fprintf(&b, "inverted: %d\n", len(r.Inverted))
+	if r.ControlPoints > 0 {
+		fmt.Fprintf(&b, "control points: %d\n", r.ControlPoints)
+	}
+	if r.Iterations > 0 {
+		fmt.Fprintf(&b, "iterations: %d\n", r.Iterations)
+	}
+	return b.String()
+}
+
+// Deform moves the points of the markers with the tags in disp, where
+// disp[tag][i] is the displacement of the i-th point of Marker.PointIds.
+// The points of the other markers stay where they are, and the remaining
+// points follow with method d.Method. Points shared by a moving marker and a
+// fixed marker move. The mesh is moved even if elements are inverted, so
+// check the report.
+func (s *SU2) Deform(disp map[string][][]float64, d Deformation) (*DeformReport, error) {
+	// The displacement of each boundary point, nil for volume points
+	bc := make([][]float64, len(s.Points))
+	for tag, rows := range disp {
+		marker := s.Marker(tag)
+		if marker == nil {
+			return nil, fmt.Errorf("deform: no marker %q", tag)
+		}
+		ids := marker.PointIds()
+		if len(rows) != len(ids) {
+			return nil, fmt.Errorf("deform: %d displacements for %d points of marker %s", len(rows), len(ids), tag)
+		}
+		for i, id := range ids {
+			if len(rows[i]) != s.Dim {
+				return nil, fmt.Errorf("deform: marker %s point %d: displacement of length %d", tag, i, len(rows[i]))
+			}
+			bc[id] = rows[i]
+		}
+	}
+	zero := make([]float64, s.Dim)
+	for _, marker := range s.Markers {
+		for _, id := range marker.PointIds() {
+			if bc[id] == nil {
+				bc[id] = zero
+			}
+		}
+	}
+
+	r := &DeformReport{}
+	var u [][]float64
+	var err error
+	switch d.Method {
+	case RBF:
+		u, r.ControlPoints, err = s.rbfDisplacement(bc, d)
+	case Spring:
+		u, r.Iterations, err = s.springDisplacement(bc, d)
+	default:
+		err = errors.New("deform: unknown method")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, point := range s.Points {
+		for j := range point.Location {
+			point.Location[j] += u[i][j]
+		}
+	}
+
+	r.MinVolume = math.Inf(1)
+	for i, elem := range s.Elements {
+		v := s.Volume(elem)
+		r.MinVolume = math.Min(r.MinVolume, v)
+		if !(v > 0) {
+			r.Inverted = append(r.Inverted, ElementID(i))
+		}
+	}
+	return r, nil
+}
+
+// wendland returns the Wendland C2 function of the distance r with support
+// radius h.
+func wendland(r, h float64) float64 {
+	q := r / h
+	if q >= 1 {
+		return 0
+	}
+	return math.Pow(1-q, 4) * (4*q + 1)
+}
+
+// rbfDisplacement returns the displacement of every point, interpolated from
+// the boundary displacements bc, and the number of control points.
+func (s *SU2) rbfDisplacement(bc [][]float64, d Deformation) ([][]float64, int, error) {
+	var boundary []int
+	var x [][]float64
+	maxDisp := 0.0
+	for i, b := range bc {
+		if b != nil {
+			boundary = append(boundary, i)
+			x = append(x, s.Points[i].Location)
+			maxDisp = math.Max(maxDisp, norm(b))
+		}
+	}
+	if len(boundary) == 0 {
+		return nil, 0, errors.New("deform: no boundary points")
+	}
+	h := d.Radius
+	if h == 0 {
+		lo, hi := bounds(x)
+		h = distance(lo, hi)
+	}
+	if !(h > 0) {
+		return nil, 0, fmt.Errorf("deform: bad RBF radius %v", h)
+	}
+
+	rbf := &rbfSystem{h: h, dim: s.Dim}
+	if d.Tolerance <= 0 {
+		for i, p := range boundary {
+			if err := rbf.add(x[i], bc[p]); err != nil {
+				return nil, 0, err
+			}
+		}
+	} else {
+		// Greedy selection, starting from the largest displacement
+		residual := make([]float64, len(boundary))
+		for i, p := range boundary {
+			residual[i] = norm(bc[p])
+		}
+		added := make([]bool, len(boundary))
+		for {
+			worst := -1
+			for i, e := range residual {
+				if !added[i] && (worst == -1 || e > residual[worst]) {
+					worst = i
+				}
+			}
+			if worst == -1 || residual[worst] <= d.Tolerance*maxDisp {
+				break
+			}
+			if err := rbf.add(x[worst], bc[boundary[worst]]); err != nil {
+				return nil, 0, err
+			}
+			added[worst] = true
+			for i, p := range boundary {
+				residual[i] = distance(rbf.eval(x[i]), bc[p])
+			}
+		}
+	}
+
+	u := make([][]float64, len(s.Points))
+	for i, point := range s.Points {
+		if bc[i] != nil {
+			u[i] = bc[i]
+		} else {
+			u[i] = rbf.eval(point.Location)
+		}
+	}
+	return u, len(rbf.centers), nil
+}
+
+// rbfRidge is added to the diagonal of the RBF interpolation matrix. With
+// a large support radius the matrix is nearly singular, and the ridge keeps
+// the Cholesky factorization stable at the cost of a tiny error at the
+// centers.
+const rbfRidge = 1e-10
+
+// rbfSystem is an RBF interpolant whose control points can be added one at a
+// time. The displacements are the least squares linear fit of the values at
+// the centers plus an RBF interpolant of what is left, so linear fields such
+// as translations are reproduced exactly. The Cholesky factor of the
+// interpolation matrix is extended with each point, so adding m points costs
+// O(m³) in total.
+type rbfSystem struct {
+	h       float64
+	dim     int
+	centers [][]float64
+	values  [][]float64 // Displacement at each center, by dimension
+	chol    [][]float64 // Rows of the lower triangular Cholesky factor
+	coef    [][]float64 // Weights of the centers, by dimension
+	linear  [][]float64 // Coefficients of the linear fit, by dimension
+	tree    *kdTree
+}
+
+// add adds the control point at x with displacement v and updates the
+// weights.
+func (r *rbfSystem) add(x, v []float64) error {
+	n := len(r.centers)
+	// The new row of the factor solves L l = k, where k holds the RBF
+	// between x and the other centers.
+	row := make([]float64, n+1)
+	for j := 0; j < n; j++ {
+		sum := wendland(distance(x, r.centers[j]), r.h)
+		for k := 0; k < j; k++ {
+			sum -= row[k] * r.chol[j][k]
+		}
+		row[j] = sum / r.chol[j][j]
+	}
+	diag := 1 + rbfRidge - dot(row[:n], row[:n])
+	if !(diag > 0) {
+		return errors.New("deform: RBF control points too close together")
+	}
+	row[n] = math.Sqrt(diag)
+	r.chol = append(r.chol, row)
+	r.centers = append(r.centers, x)
+	if r.values == nil {
+		r.values = make([][]float64, r.dim)
+	}
+	for k := range r.values {
+		r.values[k] = append(r.values[k], v[k])
+	}
+	r.fit()
+	r.tree = nil
+	return nil
+}
+
+// fit sets the linear fit and the weights of the centers.
+func (r *rbfSystem) fit() {
+	// The normal equations of the linear fit
+	m := r.dim + 1
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, m)
+	}
+	b := make([][]float64, r.dim)
+	for k := range b {
+		b[k] = make([]float64, m)
+	}
+	p := make([]float64, m)
+	for i, x := range r.centers {
+		r.basis(x, p)
+		for j := range a {
+			for l := range a[j] {
+				a[j][l] += p[j] * p[l]
+			}
+			for k := range b {
+				b[k][j] += p[j] * r.values[k][i]
+			}
+		}
+	}
+	r.linear = make([][]float64, r.dim)
+	r.coef = make([][]float64, r.dim)
+	rest := make([]float64, len(r.centers))
+	for k := range r.linear {
+		r.linear[k] = solveSemidefinite(a, b[k])
+		for i, x := range r.centers {
+			r.basis(x, p)
+			rest[i] = r.values[k][i] - dot(r.linear[k], p)
+		}
+		r.coef[k] = r.solve(rest)
+	}
+}
+
+// basis sets p to the basis of the linear fit at x, which is 1 followed by
+// the coordinates relative to the first center.
+func (r *rbfSystem) basis(x, p []float64) {
+	p[0] = 1
+	for j, c := range r.centers[0] {
+		p[j+1] = x[j] - c
+	}
+}
+
+// solveSemidefinite solves the small symmetric positive semidefinite system
+// A x = b. The unknowns that depend on the earlier ones, such as the slope
+// across a line of points, are zero.
+func solveSemidefinite(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		diag := a[j][j]
+		for k := 0; k < j; k++ {
+			diag -= l[j][k] * l[j][k]
+		}
+		if !(diag > 1e-10*a[j][j]) {
+			continue
+		}
+		l[j][j] = math.Sqrt(diag)
+		for i := j + 1; i < n; i++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			l[i][j] = sum / l[j][j]
+		}
+	}
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if l[i][i] == 0 {
+			continue
+		}
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l[i][k] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		if l[i][i] == 0 {
+			continue
+		}
+		sum := x[i]
+		for k := i + 1; k < n; k++ {
+			sum -= l[k][i] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// solve solves L Lᵀ c = b.
+func (r *rbfSystem) solve(b []float64) []float64 {
+	n := len(b)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= r.chol[i][k] * y[k]
+		}
+		y[i] = sum / r.chol[i][i]
+	}
+	for i := n - 1; i >= 0; i-- {
+		sum := y[i]
+		for k := i + 1; k < n; k++ {
+			sum -= r.chol[k][i] * y[k]
+		}
+		y[i] = sum / r.chol[i][i]
+	}
+	return y
+}
+
+// eval returns the interpolated displacement at x.
+func (r *rbfSystem) eval(x []float64) []float64 {
+	if r.tree == nil {
+		r.tree = newKDTree(r.centers)
+	}
+	p := make([]float64, r.dim+1)
+	r.basis(x, p)
+	u := make([]float64, r.dim)
+	for k := range u {
+		u[k] = dot(r.linear[k], p)
+	}
+	for _, n := range r.tree.radius(x, r.h) {
+		phi := wendland(n.d, r.h)
+		for k := range u {
+			u[k] += r.coef[k][n.i] * phi
+		}
+	}
+	return u
+}
+
+// springDisplacement returns the displacement of every point from the
+// equilibrium of a spring network on the mesh edges, with the boundary points
+// moved by bc, and the number of iterations.
+func (s *SU2) springDisplacement(bc [][]float64, d Deformation) ([][]float64, int, error) {
+	adj := s.Adjacency()
+	n := len(s.Points)
+	stiff := make([]float64, len(adj.Edges))
+	for i, e := range adj.Edges {
+		l := distance(s.Points[e[0]].Location, s.Points[e[1]].Location)
+		if l == 0 {
+			return nil, 0, fmt.Errorf("deform: edge %v has zero length", e)
+		}
+		stiff[i] = 1 / l
+	}
+	diag := make([]float64, n)
+	inv := make([]float64, n) // The Jacobi preconditioner, zero for unused points
+	for i := 0; i < n; i++ {
+		for k := adj.Offsets[i]; k < adj.Offsets[i+1]; k++ {
+			diag[i] += stiff[adj.EdgeIds[k]]
+		}
+		if diag[i] > 0 {
+			inv[i] = 1 / diag[i]
+		}
+	}
+	// apply returns K x for the free points, treating the boundary points as
+	// zero.
+	apply := func(x, out []float64) {
+		for i := 0; i < n; i++ {
+			if bc[i] != nil {
+				out[i] = 0
+				continue
+			}
+			sum := diag[i] * x[i]
+			for k := adj.Offsets[i]; k < adj.Offsets[i+1]; k++ {
+				if j := adj.Indices[k]; bc[j] == nil {
+					sum -= stiff[adj.EdgeIds[k]] * x[j]
+				}
+			}
+			out[i] = sum
+		}
+	}
+
+	maxIter := d.Iterations
+	if maxIter <= 0 {
+		maxIter = 10 * n
+	}
+	tol := d.Residual
+	if tol <= 0 {
+		tol = 1e-10
+	}
+	u := make([][]float64, n)
+	for i := range u {
+		u[i] = make([]float64, s.Dim)
+		if bc[i] != nil {
+			copy(u[i], bc[i])
+		}
+	}
+	iterations := 0
+	x := make([]float64, n)
+	b := make([]float64, n)
+	r := make([]float64, n)
+	z := make([]float64, n)
+	p := make([]float64, n)
+	q := make([]float64, n)
+	for dim := 0; dim < s.Dim; dim++ {
+		// The boundary displacements move to the right hand side.
+		for i := 0; i < n; i++ {
+			x[i], b[i] = 0, 0
+			if bc[i] != nil {
+				continue
+			}
+			for k := adj.Offsets[i]; k < adj.Offsets[i+1]; k++ {
+				if j := adj.Indices[k]; bc[j] != nil {
+					b[i] += stiff[adj.EdgeIds[k]] * bc[j][dim]
+				}
+			}
+		}
+		// Jacobi preconditioned conjugate gradient
+		copy(r, b)
+		bnorm := norm(b)
+		if bnorm == 0 {
+			continue
+		}
+		for i := range z {
+			z[i] = r[i] * inv[i]
+		}
+		copy(p, z)
+		rz := dot(r, z)
+		converged := false
+		for iter := 0; iter < maxIter; iter++ {
+			iterations++
+			apply(p, q)
+			alpha := rz / dot(p, q)
+			for i := range x {
+				x[i] += alpha * p[i]
+				r[i] -= alpha * q[i]
+			}
+			if norm(r) <= tol*bnorm {
+				converged = true
+				break
+			}
+			for i := range z {
+				z[i] = r[i] * inv[i]
+			}
+			rzNew := dot(r, z)
+			for i := range p {
+				p[i] = z[i] + rzNew/rz*p[i]
+			}
+			rz = rzNew
+		}
+		if !converged {
+			return nil, iterations, fmt.Errorf("deform: spring analogy didn't converge in %d iterations", maxIter)
+		}
+		for i := 0; i < n; i++ {
+			if bc[i] == nil {
+				u[i][dim] = x[i]
+			}
+		}
+	}
+	return u, iterations, nil
+}
@@ -0,0 +1,157 @@
+package mesh
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDeform(t *testing.T) {
+	channel := Channel{Length: 4, Height: 1, NX: 41, NY: 11}
+	for _, d := range []Deformation{
+		{Method: RBF},
+		{Method: RBF, Radius: 1.5, Tolerance: 1e-3},
+		{Method: Spring},
+	} {
+		s, err := channel.Mesh()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A bump on the lower wall
+		ids := s.Marker("wall").PointIds()
+		disp := make([][]float64, len(ids))
+		want := make(map[PointID][]float64)
+		for i, id := range ids {
+			x := s.Points[id].Location
+			disp[i] = []float64{0, 0}
+			if x[1] == 0 {
+				disp[i][1] = 0.2 * math.Sin(math.Pi*x[0]/4)
+			}
+			want[id] = []float64{x[0] + disp[i][0], x[1] + disp[i][1]}
+		}
+		inlet := s.Marker("inlet").PointIds()
+		before := make([][]float64, len(inlet))
+		for i, id := range inlet {
+			before[i] = append([]float64(nil), s.Points[id].Location...)
+		}
+		r, err := s.Deform(map[string][][]float64{"wall": disp}, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.MinVolume <= 0 || len(r.Inverted) != 0 {
+			t.Errorf("method %d: inverted elements: %v", d.Method, r)
+		}
+		for id, x := range want {
+			if !closeTo(s.Points[id].Location, x, 1e-15) {
+				t.Errorf("method %d: wall point %d at %v, expected %v", d.Method, id, s.Points[id].Location, x)
+			}
+		}
+		for i, id := range inlet {
+			if !closeTo(s.Points[id].Location, before[i], 0) {
+				t.Errorf("method %d: fixed point %d moved", d.Method, id)
+			}
+		}
+		// The middle of the channel moves up, but less than the wall.
+		mid := s.Points[20+5*41].Location
+		if !(mid[1] > 0.5 && mid[1] < 0.7) {
+			t.Errorf("method %d: midpoint at %v", d.Method, mid)
+		}
+		switch d.Method {
+		case RBF:
+			if d.Tolerance == 0 && r.ControlPoints != 100 {
+				t.Errorf("%d control points, expected 100", r.ControlPoints)
+			}
+			if d.Tolerance > 0 && (r.ControlPoints == 0 || r.ControlPoints >= 100) {
+				t.Errorf("%d control points with greedy selection", r.ControlPoints)
+			}
+		case Spring:
+			if r.Iterations == 0 {
+				t.Errorf("no spring iterations")
+			}
+		}
+	}
+
+	// Moving the wall through the channel inverts elements.
+	s, err := channel.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := s.Marker("wall").PointIds()
+	disp := make([][]float64, len(ids))
+	for i, id := range ids {
+		disp[i] = []float64{0, 0}
+		if x := s.Points[id].Location; x[1] == 0 && x[0] > 1 && x[0] < 3 {
+			disp[i][1] = 2
+		}
+	}
+	r, err := s.Deform(map[string][][]float64{"wall": disp}, Deformation{Method: Spring})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MinVolume > 0 || len(r.Inverted) == 0 {
+		t.Errorf("no inverted elements: %v", r)
+	}
+	if _, err := s.Deform(map[string][][]float64{"wall": disp[1:]}, Deformation{}); err == nil {
+		t.Errorf("no error for missing displacements")
+	}
+}
+
+func TestDeformFlatPlate(t *testing.T) {
+	read := func() *SU2 {
+		f, err := os.Open("mesh_flatplate_turb_137x97.su2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		s := &SU2{SkipNeighbors: true}
+		if _, err := s.ReadFrom(f); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// A bump on the plate, which is clustered so tightly that the RBF
+	// matrix is close to singular.
+	s := read()
+	ids := s.Marker("wall").PointIds()
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, id := range ids {
+		x := s.Points[id].Location[0]
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+	}
+	disp := make([][]float64, len(ids))
+	for i, id := range ids {
+		x := s.Points[id].Location[0]
+		disp[i] = []float64{0, 0.005 * math.Sin(math.Pi*(x-lo)/(hi-lo))}
+	}
+	r, err := s.Deform(map[string][][]float64{"wall": disp}, Deformation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Inverted) != 0 {
+		t.Errorf("inverted elements: %v", r)
+	}
+
+	// A rigid translation of the boundary moves every point.
+	s = read()
+	orig := read()
+	move := []float64{0.3, -0.2}
+	all := make(map[string][][]float64)
+	for _, marker := range s.Markers {
+		rows := make([][]float64, len(marker.PointIds()))
+		for i := range rows {
+			rows[i] = move
+		}
+		all[marker.Tag] = rows
+	}
+	if _, err := s.Deform(all, Deformation{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, point := range s.Points {
+		want := []float64{orig.Points[i].Location[0] + move[0], orig.Points[i].Location[1] + move[1]}
+		if !closeTo(point.Location, want, 1e-12) {
+			t.Errorf("point %d at %v, expected %v", i, point.Location, want)
+			break
+		}
+	}
+}
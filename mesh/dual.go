@@ -0,0 +1,238 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+)
+
+// DualGrid is the median-dual control volume discretization used by SU2's
+// edge-based finite volume solvers. The control volume of a point is bounded
+// by faces joining edge midpoints, face centroids and element centroids.
+type DualGrid struct {
+	// Edges are the edges of the mesh, as in Adjacency.
+	Edges []Edge
+	// EdgeNormals are the area-weighted normals of the dual faces crossing
+	// each edge, pointing from Edges[i][0] to Edges[i][1].
+	EdgeNormals [][]float64
+	// Volumes are the volumes of the control volumes of each point.
+	Volumes []float64
+	// Boundaries hold the boundary vertices of each marker, in the order of
+	// SU2.Markers.
+	Boundaries []DualBoundary
+}
+
+// DualBoundary holds the boundary vertices of a marker.
+type DualBoundary struct {
+	Tag      string
+	Vertices []BoundaryVertex
+}
+
+// BoundaryVertex is a point on a marker with the area-weighted normal of the
+// part of the marker surface that closes its control volume. As in SU2's
+// CVertex, the normal points into the domain.
+type BoundaryVertex struct {
+	Point  PointID
+	Normal []float64
+}
+
+// edgeFaces lists, for each local edge of a 3D element type, the two local
+// faces that contain it.
+var edgeFaces = make(map[VTKType][][2]int)
+
+func init() {
+	for t, info := range elementTypes {
+		if info.dim != 3 {
+			continue
+		}
+		list := make([][2]int, len(info.edges))
+		for i, edge := range info.edges {
+			n := 0
+			for j, face := range info.faces {
+				if faceHasEdge(face, edge) {
+					list[i][n] = j
+					n++
+				}
+			}
+			if n != 2 {
+				panic("mesh: edge not shared by two faces")
+			}
+		}
+		edgeFaces[t] = list
+	}
+}
+
+// faceFollows returns true if the face goes around from edge[0] to edge[1].
+func faceFollows(face []int, edge [2]int) bool {
+	for i, a := range face {
+		if a == edge[0] {
+			return face[(i+1)%len(face)] == edge[1]
+		}
+	}
+	return false
+}
+
+func faceHasEdge(face []int, edge [2]int) bool {
+	for i, a := range face {
+		b := face[(i+1)%len(face)]
+		if (a == edge[0] && b == edge[1]) || (a == edge[1] && b == edge[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DualGrid computes the median-dual grid of the mesh. The edge normals, point
+// volumes and boundary normals follow the construction in SU2's
+// CPhysicalGeometry::SetControlVolume and SetBoundControlVolume. As in SU2,
+// the normals are oriented from the vertex order of the elements, which must
+// have positive volumes.
+func (s *SU2) DualGrid() (*DualGrid, error) {
+	if s.Dim != 2 && s.Dim != 3 {
+		return nil, fmt.Errorf("dual grid of a mesh with dimension %d", s.Dim)
+	}
+	adj := s.Adjacency()
+	d := &DualGrid{
+		Edges:       adj.Edges,
+		EdgeNormals: make([][]float64, len(adj.Edges)),
+		Volumes:     make([]float64, len(s.Points)),
+	}
+	for i := range d.EdgeNormals {
+		d.EdgeNormals[i] = make([]float64, s.Dim)
+	}
+
+	for _, elem := range s.Elements {
+		if elem.Type.Dim() != s.Dim {
+			return nil, fmt.Errorf("element %d: %v element in a %dD mesh", elem.Id, elem.Type, s.Dim)
+		}
+		x := s.coords(elem)
+		center := average(x)
+		var faceCenters [][]float64
+		if s.Dim == 3 {
+			faces := elem.Type.Faces()
+			faceCenters = make([][]float64, len(faces))
+			for i, face := range faces {
+				fx := make([][]float64, len(face))
+				for j, local := range face {
+					fx[j] = x[local]
+				}
+				faceCenters[i] = average(fx)
+			}
+		}
+		for i, edge := range elem.Type.Edges() {
+			a, b := elem.VertexIds[edge[0]], elem.VertexIds[edge[1]]
+			xa, xb := x[edge[0]], x[edge[1]]
+			mid := average([][]float64{xa, xb})
+			e, _ := adj.Edge(a, b)
+			// The normals are built pointing from a to b, and are flipped
+			// for edges stored from b to a.
+			sign := 1.0
+			if d.Edges[e][0] != a {
+				sign = -1
+			}
+
+			if s.Dim == 2 {
+				// The dual face joins the edge midpoint and the element
+				// centroid, which is on the left going around the
+				// counterclockwise element.
+				n := faceNormal([][]float64{mid, center})
+				if edge[1] != (edge[0]+1)%len(x) {
+					sign = -sign
+				}
+				addScaled(d.EdgeNormals[e], n, sign)
+				d.Volumes[a] += math.Abs(triangleArea(xa, mid, center))
+				d.Volumes[b] += math.Abs(triangleArea(xb, mid, center))
+				continue
+			}
+			// In 3D the dual face is made of two triangles joining the edge
+			// midpoint, the centroids of the faces sharing the edge and the
+			// element centroid. The outward faces go around the edge in
+			// opposite directions, which orients the triangles.
+			f := edgeFaces[elem.Type][i]
+			forward, backward := faceCenters[f[0]], faceCenters[f[1]]
+			if !faceFollows(elem.Type.Faces()[f[0]], edge) {
+				forward, backward = backward, forward
+			}
+			addScaled(d.EdgeNormals[e], faceNormal([][]float64{mid, center, forward}), sign)
+			addScaled(d.EdgeNormals[e], faceNormal([][]float64{mid, backward, center}), sign)
+			for _, fc := range [][]float64{forward, backward} {
+				d.Volumes[a] += math.Abs(tetVolume(xa, mid, fc, center))
+				d.Volumes[b] += math.Abs(tetVolume(xb, mid, fc, center))
+			}
+		}
+	}
+
+	faces := s.faces()
+	d.Boundaries = make([]DualBoundary, len(s.Markers))
+	for i, marker := range s.Markers {
+		index := make(map[PointID]int)
+		boundary := DualBoundary{Tag: marker.Tag}
+		for j := range marker.Elements {
+			elem := &marker.Elements[j]
+			refs := faces[newFaceKey(elem.VertexIds)]
+			if len(refs) == 0 {
+				return nil, fmt.Errorf("marker %s element %d is not a face of the mesh", marker.Tag, j)
+			}
+			// The normals of the parts follow the order of the marker
+			// element, so they point into the domain if it goes around the
+			// opposite way to the outward face of its element.
+			owner := s.Elements[refs[0].Element]
+			face := owner.Type.Faces()[refs[0].Face]
+			sign := 1.0
+			if sameCycle(elem.VertexIds, owner.VertexIds, face) {
+				sign = -1
+			}
+			x := s.coords(elem)
+			center := average(x)
+			for k, id := range elem.VertexIds {
+				var n []float64
+				if s.Dim == 2 {
+					n = scale(faceNormal(x), 0.5*sign)
+				} else {
+					// The part of the face closest to the vertex is bounded
+					// by the vertex, the midpoints of its edges and the face
+					// centroid.
+					prev := average([][]float64{x[k], x[(k+len(x)-1)%len(x)]})
+					next := average([][]float64{x[k], x[(k+1)%len(x)]})
+					n = make([]float64, 3)
+					addScaled(n, faceNormal([][]float64{x[k], next, center}), sign)
+					addScaled(n, faceNormal([][]float64{x[k], center, prev}), sign)
+				}
+				v, ok := index[id]
+				if !ok {
+					v = len(boundary.Vertices)
+					index[id] = v
+					boundary.Vertices = append(boundary.Vertices, BoundaryVertex{
+						Point:  id,
+						Normal: make([]float64, s.Dim),
+					})
+				}
+				for l := range n {
+					boundary.Vertices[v].Normal[l] += n[l]
+				}
+			}
+		}
+		d.Boundaries[i] = boundary
+	}
+	return d, nil
+}
+
+// sameCycle returns true if the ids go around in the same direction as the
+// vertices of the element face.
+func sameCycle(ids, vertices []PointID, face []int) bool {
+	for i, local := range face {
+		if vertices[local] == ids[0] {
+			return vertices[face[(i+1)%len(face)]] == ids[1]
+		}
+	}
+	return false
+}
+
+// triangleArea returns the signed area of a 2D triangle.
+func triangleArea(a, b, c []float64) float64 {
+	return ((b[0]-a[0])*(c[1]-a[1]) - (c[0]-a[0])*(b[1]-a[1])) / 2
+}
+
+// tetVolume returns the signed volume of a tetrahedron.
+func tetVolume(a, b, c, d []float64) float64 {
+	return dot(cross(sub(b, a), sub(c, a)), sub(d, a)) / 6
+}
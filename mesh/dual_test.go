@@ -0,0 +1,137 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// addBoundaryMarker adds a marker with all of the boundary faces of the mesh.
+func addBoundaryMarker(s *SU2, tag string) {
+	marker := &Marker{Tag: tag}
+	for _, refs := range s.faces() {
+		if len(refs) != 1 {
+			continue
+		}
+		elem := s.Elements[refs[0].Element]
+		face := elem.Type.Faces()[refs[0].Face]
+		ids := make([]PointID, len(face))
+		for i, local := range face {
+			ids[i] = elem.VertexIds[local]
+		}
+		marker.Elements = append(marker.Elements, Element{Id: -1, Type: faceType(len(face)), VertexIds: ids})
+	}
+	s.Markers = append(s.Markers, marker)
+}
+
+func TestDualGrid(t *testing.T) {
+	quads := structuredQuads(3, 3)
+	if err := quads.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		s      *SU2
+		volume float64
+	}{
+		{"quads", quads, 4},
+		{"hybrid2D", readString(t, hybrid2D), 1},
+		{"hybrid3D", readString(t, hybrid3D), 1 + 1.0/6 + 1.0/12 + 0.5},
+	} {
+		s := test.s
+		s.Markers = nil
+		addBoundaryMarker(s, "boundary")
+		d, err := s.DualGrid()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var volume float64
+		for _, v := range d.Volumes {
+			volume += v
+		}
+		if math.Abs(volume-test.volume) > 1e-14 {
+			t.Errorf("%s: volume mismatch. Expected %v, found %v", test.name, test.volume, volume)
+		}
+		// Each control volume is closed: the outward normals of its faces sum
+		// to zero.
+		sum := make([][]float64, len(s.Points))
+		for i := range sum {
+			sum[i] = make([]float64, s.Dim)
+		}
+		for i, edge := range d.Edges {
+			for j, n := range d.EdgeNormals[i] {
+				sum[edge[0]][j] += n
+				sum[edge[1]][j] -= n
+			}
+		}
+		for _, v := range d.Boundaries[0].Vertices {
+			for j, n := range v.Normal {
+				sum[v.Point][j] -= n
+			}
+		}
+		for i := range sum {
+			if !closeTo(sum[i], make([]float64, s.Dim), 1e-14) {
+				t.Errorf("%s: control volume %d not closed: %v", test.name, i, sum[i])
+			}
+		}
+	}
+
+	d, err := quads.DualGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{0.25, 0.5, 0.25, 0.5, 1, 0.5, 0.25, 0.5, 0.25} {
+		if math.Abs(d.Volumes[i]-want) > 1e-14 {
+			t.Errorf("Volume mismatch at point %d. Expected %v, found %v", i, want, d.Volumes[i])
+		}
+	}
+	e, _ := quads.Adjacency().Edge(4, 5)
+	if !closeTo(d.EdgeNormals[e], []float64{1, 0}, 1e-14) {
+		t.Errorf("Edge normal mismatch. Expected %v, found %v", []float64{1, 0}, d.EdgeNormals[e])
+	}
+	for _, v := range d.Boundaries[0].Vertices {
+		if v.Point == 1 && !closeTo(v.Normal, []float64{0, 1}, 1e-14) {
+			t.Errorf("Boundary normal mismatch. Expected %v, found %v", []float64{0, 1}, v.Normal)
+		}
+	}
+}
+
+func TestDualGridSkewed(t *testing.T) {
+	// A strongly distorted hexahedron, where some of the dual face triangles
+	// point against their edges.
+	s := &SU2{Dim: 3}
+	for i, x := range [][]float64{
+		{-0.60, -0.39, 0.11}, {0.30, 0.49, -0.07}, {1.54, 0.60, 0.32}, {0.60, 1.00, 0.33},
+		{-0.22, -0.58, 0.69}, {1.45, -0.59, 0.46}, {1.36, 1.23, 1.28}, {0.05, 0.35, 1.21},
+	} {
+		s.Points = append(s.Points, &Point{Id: PointID(i), Location: x})
+	}
+	s.Elements = []*Element{{Id: 0, Type: Hexahedron, VertexIds: []PointID{0, 1, 2, 3, 4, 5, 6, 7}}}
+	addBoundaryMarker(s, "boundary")
+	if err := s.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	d, err := s.DualGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := make([][]float64, len(s.Points))
+	for i := range sum {
+		sum[i] = make([]float64, 3)
+	}
+	for i, edge := range d.Edges {
+		for j, n := range d.EdgeNormals[i] {
+			sum[edge[0]][j] += n
+			sum[edge[1]][j] -= n
+		}
+	}
+	for _, v := range d.Boundaries[0].Vertices {
+		for j, n := range v.Normal {
+			sum[v.Point][j] -= n
+		}
+	}
+	for i := range sum {
+		if !closeTo(sum[i], []float64{0, 0, 0}, 1e-14) {
+			t.Errorf("control volume %d not closed: %v", i, sum[i])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mesh
+
+// Element types follow the VTK numbering and node ordering used by SU2. For
+// the definitions, see http://www.vtk.org/VTK/img/file-formats.pdf
+const (
+	Line          VTKType = 3
+	Triangle      VTKType = 5
+	Quadrilateral VTKType = 9
+	Tetrahedron   VTKType = 10
+	Hexahedron    VTKType = 12
+	Prism         VTKType = 13
+	Pyramid       VTKType = 14
+)
+
+// elementInfo describes the local topology of an element type. Edges are pairs
+// of local node indices. Faces are lists of local node indices ordered so that
+// the right-hand rule gives a normal pointing out of a positively oriented
+// element.
+type elementInfo struct {
+	name  string
+	dim   int
+	nodes int
+	edges [][2]int
+	faces [][]int
+}
+
+var elementTypes = map[VTKType]elementInfo{
+	Line: {
+		name:  "line",
+		dim:   1,
+		nodes: 2,
+		edges: [][2]int{{0, 1}},
+	},
+	Triangle: {
+		name:  "triangle",
+		dim:   2,
+		nodes: 3,
+		edges: [][2]int{{0, 1}, {1, 2}, {2, 0}},
+		faces: [][]int{{0, 1}, {1, 2}, {2, 0}},
+	},
+	Quadrilateral: {
+		name:  "quadrilateral",
+		dim:   2,
+		nodes: 4,
+		edges: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}},
+		faces: [][]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}},
+	},
+	Tetrahedron: {
+		name:  "tetrahedron",
+		dim:   3,
+		nodes: 4,
+		edges: [][2]int{{0, 1}, {1, 2}, {2, 0}, {0, 3}, {1, 3}, {2, 3}},
+		faces: [][]int{{0, 2, 1}, {0, 1, 3}, {1, 2, 3}, {0, 3, 2}},
+	},
+	Hexahedron: {
+		name:  "hexahedron",
+		dim:   3,
+		nodes: 8,
+		edges: [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 0},
+			{4, 5}, {5, 6}, {6, 7}, {7, 4},
+			{0, 4}, {1, 5}, {2, 6}, {3, 7},
+		},
+		faces: [][]int{
+			{0, 3, 2, 1}, {4, 5, 6, 7},
+			{0, 1, 5, 4}, {1, 2, 6, 5}, {2, 3, 7, 6}, {3, 0, 4, 7},
+		},
+	},
+	Prism: {
+		name:  "prism",
+		dim:   3,
+		nodes: 6,
+		edges: [][2]int{
+			{0, 1}, {1, 2}, {2, 0},
+			{3, 4}, {4, 5}, {5, 3},
+			{0, 3}, {1, 4}, {2, 5},
+		},
+		faces: [][]int{
+			{0, 1, 2}, {3, 5, 4},
+			{0, 3, 4, 1}, {1, 4, 5, 2}, {2, 5, 3, 0},
+		},
+	},
+	Pyramid: {
+		name:  "pyramid",
+		dim:   3,
+		nodes: 5,
+		edges: [][2]int{
+			{0, 1}, {1, 2}, {2, 3}, {3, 0},
+			{0, 4}, {1, 4}, {2, 4}, {3, 4},
+		},
+		faces: [][]int{
+			{0, 3, 2, 1},
+			{0, 1, 4}, {1, 2, 4}, {2, 3, 4}, {3, 0, 4},
+		},
+	},
+}
+
+func (v VTKType) String() string {
+	info, ok := elementTypes[v]
+	if !ok {
+		return "unknown"
+	}
+	return info.name
+}
+
+// Supported returns true if the element type is one of the known VTK types.
+func (v VTKType) Supported() bool {
+	_, ok := elementTypes[v]
+	return ok
+}
+
+// NumNodes returns the number of nodes in an element of this type, or zero if
+// the type is not supported.
+func (v VTKType) NumNodes() int {
+	return elementTypes[v].nodes
+}
+
+// Dim returns the topological dimension of the element type (1 for lines,
+// 2 for triangles and quadrilaterals, 3 for volume elements).
+func (v VTKType) Dim() int {
+	return elementTypes[v].dim
+}
+
+// Edges returns the edges of the element type as pairs of local node indices.
+// The returned slice must not be modified.
+func (v VTKType) Edges() [][2]int {
+	return elementTypes[v].edges
+}
+
+// Faces returns the faces of the element type as lists of local node indices,
+// ordered so their normals point out of the element. In 2D the faces are the
+// element edges. The returned slice must not be modified.
+func (v VTKType) Faces() [][]int {
+	return elementTypes[v].faces
+}
+
+// faceType returns the element type of a face with the given number of nodes.
+func faceType(nodes int) VTKType {
+	switch nodes {
+	case 2:
+		return Line
+	case 3:
+		return Triangle
+	case 4:
+		return Quadrilateral
+	}
+	panic("mesh: bad face size")
+}
@@ -0,0 +1,294 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Extrude returns a 3D mesh one cell thick made by extruding the 2D mesh from
+// z = 0 to z = depth. Triangles become prisms and quadrilaterals become
+// hexahedra. The markers become markers of the quadrilateral side faces, and
+// the faces at z = 0 and z = depth are added as markers with the tags front
+// and back, which are usually symmetry planes. If front and back are the same
+// tag both faces are in one marker.
+func (s *SU2) Extrude(depth float64, front, back string) (*SU2, error) {
+	if s.Dim != 2 {
+		return nil, fmt.Errorf("extrude: %dD mesh", s.Dim)
+	}
+	if !(depth > 0) || math.IsInf(depth, 1) {
+		return nil, fmt.Errorf("extrude: bad depth %v", depth)
+	}
+	if front == "" || back == "" {
+		return nil, fmt.Errorf("extrude: empty marker tag")
+	}
+	n := PointID(len(s.Points))
+	out := &SU2{Dim: 3, SkipNeighbors: s.SkipNeighbors}
+	out.Points = make([]*Point, 2*n)
+	for i, point := range s.Points {
+		x, y := point.Location[0], point.Location[1]
+		out.Points[i] = &Point{Id: PointID(i), Location: []float64{x, y, 0}}
+		out.Points[PointID(i)+n] = &Point{Id: PointID(i) + n, Location: []float64{x, y, depth}}
+	}
+
+	frontMarker := &Marker{Tag: front}
+	backMarker := frontMarker
+	if back != front {
+		backMarker = &Marker{Tag: back}
+	}
+	for i, elem := range s.Elements {
+		v := append([]PointID(nil), elem.VertexIds...)
+		if s.Volume(elem) < 0 {
+			// Counterclockwise vertices extrude to positive elements.
+			reversePoints(v)
+		}
+		top := make([]PointID, len(v))
+		for j, id := range v {
+			top[j] = id + n
+		}
+		// The bottom face points in -z, so it is clockwise seen from +z.
+		bottom := append([]PointID(nil), v...)
+		reversePoints(bottom[1:])
+		var e *Element
+		switch elem.Type {
+		case Triangle:
+			e = &Element{Type: Prism, VertexIds: []PointID{v[0], v[2], v[1], top[0], top[2], top[1]}}
+		case Quadrilateral:
+			e = &Element{Type: Hexahedron, VertexIds: append(append([]PointID(nil), v...), top...)}
+		default:
+			return nil, fmt.Errorf("extrude: element %d is a %v", i, elem.Type)
+		}
+		e.Id = ElementID(i)
+		out.Elements = append(out.Elements, e)
+		frontMarker.Elements = append(frontMarker.Elements, Element{Id: -1, Type: faceType(len(v)), VertexIds: bottom})
+		backMarker.Elements = append(backMarker.Elements, Element{Id: -1, Type: faceType(len(v)), VertexIds: top})
+	}
+
+	for _, marker := range s.Markers {
+		m := &Marker{Tag: marker.Tag, Elements: make([]Element, len(marker.Elements))}
+		for i, elem := range marker.Elements {
+			if elem.Type != Line {
+				return nil, fmt.Errorf("extrude: marker %s element %d is a %v", marker.Tag, i, elem.Type)
+			}
+			// The right-hand rule on (p, q, q', p') gives the 2D normal of
+			// the line from p to q.
+			p, q := elem.VertexIds[0], elem.VertexIds[1]
+			m.Elements[i] = Element{Id: -1, Type: Quadrilateral, VertexIds: []PointID{p, q, q + n, p + n}}
+		}
+		out.Markers = append(out.Markers, m)
+	}
+	out.Markers = append(out.Markers, frontMarker)
+	if backMarker != frontMarker {
+		out.Markers = append(out.Markers, backMarker)
+	}
+	if err := out.initialize(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// sliceKey identifies a point of a slice: a 3D point on the plane, with the
+// second id -1, or the crossing of the edge between two 3D points.
+type sliceKey [2]PointID
+
+// slicer cuts the elements and faces of a 3D mesh with a plane.
+type slicer struct {
+	s      *SU2
+	dist   []float64   // Signed distance of each point from the plane
+	origin []float64   // A point on the plane
+	axes   [][]float64 // In-plane axes of the 2D mesh
+	out    *SU2
+	ids    map[sliceKey]PointID
+}
+
+// cut returns the keys of the points where the edges of the element cross
+// the plane, in no particular order and without repeats. Points on the plane
+// count as being above it.
+func (sl *slicer) cut(elem *Element) []sliceKey {
+	var keys []sliceKey
+	for _, edge := range elem.Type.Edges() {
+		a, b := elem.VertexIds[edge[0]], elem.VertexIds[edge[1]]
+		da, db := sl.dist[a], sl.dist[b]
+		if (da >= 0) == (db >= 0) {
+			continue
+		}
+		var key sliceKey
+		switch {
+		case da == 0:
+			key = sliceKey{a, -1}
+		case db == 0:
+			key = sliceKey{b, -1}
+		case a < b:
+			key = sliceKey{a, b}
+		default:
+			key = sliceKey{b, a}
+		}
+		dup := false
+		for _, other := range keys {
+			dup = dup || other == key
+		}
+		if !dup {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// point returns the 2D point for the key, adding it if needed.
+func (sl *slicer) point(key sliceKey) PointID {
+	if id, ok := sl.ids[key]; ok {
+		return id
+	}
+	x := sl.s.Points[key[0]].Location
+	if key[1] != -1 {
+		y := sl.s.Points[key[1]].Location
+		da, db := sl.dist[key[0]], sl.dist[key[1]]
+		t := da / (da - db)
+		x = append([]float64(nil), x...)
+		for i := range x {
+			x[i] += t * (y[i] - x[i])
+		}
+	}
+	r := sub(x, sl.origin)
+	id := PointID(len(sl.out.Points))
+	sl.out.Points = append(sl.out.Points, &Point{
+		Id:       id,
+		Location: []float64{dot(r, sl.axes[0]), dot(r, sl.axes[1])},
+	})
+	sl.ids[key] = id
+	return id
+}
+
+// Slice returns the 2D mesh of the cut of the 3D mesh by the plane through
+// point with the given normal. Each element crossing the plane gives a
+// triangle or quadrilateral, or triangles in a fan for the pentagons and
+// hexagons from cutting prisms and hexahedra. The 2D coordinates are along
+// two perpendicular axes in the plane which, with the normal, follow the
+// right-hand rule. The markers crossing the plane become markers of the
+// boundary edges of the slice.
+func (s *SU2) Slice(point, normal []float64) (*SU2, error) {
+	if s.Dim != 3 {
+		return nil, fmt.Errorf("slice: %dD mesh", s.Dim)
+	}
+	if len(point) != 3 || len(normal) != 3 || norm(normal) == 0 {
+		return nil, fmt.Errorf("slice: bad plane through %v with normal %v", point, normal)
+	}
+	nz := scale(append([]float64(nil), normal...), 1/norm(normal))
+	// The first axis is the coordinate axis most perpendicular to the normal,
+	// made perpendicular to it.
+	ax := []float64{0, 0, 0}
+	smallest := 0
+	for i := range nz {
+		if math.Abs(nz[i]) < math.Abs(nz[smallest]) {
+			smallest = i
+		}
+	}
+	ax[smallest] = 1
+	ax = sub(ax, scale(append([]float64(nil), nz...), nz[smallest]))
+	scale(ax, 1/norm(ax))
+	ay := cross(nz, ax)
+
+	sl := &slicer{
+		s:      s,
+		dist:   make([]float64, len(s.Points)),
+		origin: point,
+		axes:   [][]float64{ax, ay},
+		out:    &SU2{Dim: 2, SkipNeighbors: s.SkipNeighbors},
+		ids:    make(map[sliceKey]PointID),
+	}
+	for i, p := range s.Points {
+		sl.dist[i] = dot(sub(p.Location, point), nz)
+	}
+
+	// The directed boundary edges of the slice, by their sorted points
+	edges := make(map[[2]PointID][2]PointID)
+	for _, elem := range s.Elements {
+		keys := sl.cut(elem)
+		if len(keys) < 3 {
+			continue
+		}
+		ids := make([]PointID, len(keys))
+		for i, key := range keys {
+			ids[i] = sl.point(key)
+		}
+		sl.order(ids)
+		var polys [][]PointID
+		switch len(ids) {
+		case 3, 4:
+			polys = [][]PointID{ids}
+		default:
+			for j := 1; j+1 < len(ids); j++ {
+				polys = append(polys, []PointID{ids[0], ids[j], ids[j+1]})
+			}
+		}
+		for _, poly := range polys {
+			e := &Element{
+				Id:        ElementID(len(sl.out.Elements)),
+				Type:      faceType(len(poly)),
+				VertexIds: poly,
+			}
+			sl.out.Elements = append(sl.out.Elements, e)
+			for j := range poly {
+				a, b := poly[j], poly[(j+1)%len(poly)]
+				key := [2]PointID{a, b}
+				if b < a {
+					key = [2]PointID{b, a}
+				}
+				if _, ok := edges[key]; ok {
+					delete(edges, key)
+				} else {
+					edges[key] = [2]PointID{a, b}
+				}
+			}
+		}
+	}
+	if len(sl.out.Elements) == 0 {
+		return nil, fmt.Errorf("slice: plane doesn't cross the mesh")
+	}
+
+	for _, marker := range s.Markers {
+		m := &Marker{Tag: marker.Tag}
+		for i := range marker.Elements {
+			keys := sl.cut(&marker.Elements[i])
+			if len(keys) != 2 {
+				continue
+			}
+			a, okA := sl.ids[keys[0]]
+			b, okB := sl.ids[keys[1]]
+			if !okA || !okB {
+				continue
+			}
+			key := [2]PointID{a, b}
+			if key[1] < key[0] {
+				key[0], key[1] = key[1], key[0]
+			}
+			edge, ok := edges[key]
+			if !ok {
+				continue
+			}
+			m.Elements = append(m.Elements, Element{Id: -1, Type: Line, VertexIds: []PointID{edge[0], edge[1]}})
+		}
+		if len(m.Elements) > 0 {
+			sl.out.Markers = append(sl.out.Markers, m)
+		}
+	}
+	if err := sl.out.initialize(); err != nil {
+		return nil, err
+	}
+	return sl.out, nil
+}
+
+// order sorts the points of a convex polygon counterclockwise around their
+// center.
+func (sl *slicer) order(ids []PointID) {
+	x := make([][]float64, len(ids))
+	for i, id := range ids {
+		x[i] = sl.out.Points[id].Location
+	}
+	c := average(x)
+	angle := make(map[PointID]float64, len(ids))
+	for i, id := range ids {
+		angle[id] = math.Atan2(x[i][1]-c[1], x[i][0]-c[0])
+	}
+	sort.Slice(ids, func(i, j int) bool { return angle[ids[i]] < angle[ids[j]] })
+}
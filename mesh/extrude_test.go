@@ -0,0 +1,120 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExtrude(t *testing.T) {
+	s := readString(t, hybrid2D)
+	s.Markers = nil
+	addBoundaryMarker(s, "boundary")
+	out, err := s.Extrude(0.5, "front", "back")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Points) != 12 || len(out.Elements) != 3 {
+		t.Fatalf("size mismatch: %d points and %d elements", len(out.Points), len(out.Elements))
+	}
+	var volume float64
+	for _, elem := range out.Elements {
+		v := out.Volume(elem)
+		if v <= 0 {
+			t.Errorf("%v %d has volume %v", elem.Type, elem.Id, v)
+		}
+		volume += v
+	}
+	if math.Abs(volume-0.5) > 1e-15 {
+		t.Errorf("volume mismatch. Expected 0.5, found %v", volume)
+	}
+	for _, test := range []struct {
+		tag    string
+		count  int
+		normal []float64
+	}{
+		{"boundary", 6, nil},
+		{"front", 3, []float64{0, 0, -1}},
+		{"back", 3, []float64{0, 0, 1}},
+	} {
+		marker := out.Marker(test.tag)
+		if marker == nil || len(marker.Elements) != test.count {
+			t.Fatalf("marker %s mismatch: %v", test.tag, marker)
+		}
+		normals, err := out.MarkerNormals(marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range marker.Elements {
+			n := out.Normal(&marker.Elements[i])
+			if !closeTo(n, normals[i], 1e-15) {
+				t.Errorf("marker %s element %d: not outward", test.tag, i)
+			}
+			if test.normal != nil && !closeTo(scale(n, 1/norm(n)), test.normal, 1e-15) {
+				t.Errorf("marker %s element %d: normal %v", test.tag, i, n)
+			}
+		}
+	}
+	if issues := out.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in extruded mesh: %v", issues)
+	}
+
+	out, err = s.Extrude(1, "symmetry", "symmetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := out.Marker("symmetry"); len(out.Markers) != 2 || m == nil || len(m.Elements) != 6 {
+		t.Errorf("symmetry marker mismatch: %v", out.Markers)
+	}
+	if _, err := out.Extrude(1, "front", "back"); err == nil {
+		t.Errorf("no error for extruding a 3D mesh")
+	}
+}
+
+func TestSlice(t *testing.T) {
+	// Slicing an extruded mesh gives back the 2D mesh.
+	s, err := Channel{Length: 4, Height: 1, NX: 9, NY: 5}.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s3, err := s.Extrude(1, "symmetry", "symmetry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slice, err := s3.Slice([]float64{0, 0, 0.25}, []float64{0, 0, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slice.Points) != len(s.Points) || len(slice.Elements) != len(s.Elements) {
+		t.Fatalf("size mismatch: %d points and %d elements", len(slice.Points), len(slice.Elements))
+	}
+	for _, elem := range slice.Elements {
+		if v := slice.Volume(elem); math.Abs(v-0.125) > 1e-15 {
+			t.Errorf("element %d: area %v", elem.Id, v)
+		}
+	}
+	if len(slice.Markers) != len(s.Markers) {
+		t.Errorf("marker mismatch: %v", slice.Markers)
+	}
+	for _, marker := range s.Markers {
+		if m := slice.Marker(marker.Tag); m == nil || len(m.Elements) != len(marker.Elements) {
+			t.Errorf("marker %s mismatch: %v", marker.Tag, m)
+		}
+	}
+	if issues := slice.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in slice: %v", issues)
+	}
+
+	// An oblique slice of every element type
+	s3 = readString(t, hybrid3D)
+	addBoundaryMarker(s3, "boundary")
+	slice, err = s3.Slice([]float64{0.5, 0.5, 0.6}, []float64{1, 0.5, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := slice.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in slice: %v", issues)
+	}
+	if _, err := s3.Slice([]float64{0, 0, 10}, []float64{0, 0, 1}); err == nil {
+		t.Errorf("no error for plane outside the mesh")
+	}
+}
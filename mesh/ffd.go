@@ -0,0 +1,268 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// FFDBox is a free-form deformation box: a lattice of Bernstein polynomial
+// control points around the points of some markers. Moving the control points
+// moves the marker points inside the box smoothly. The box is aligned with the
+// coordinate axes.
+type FFDBox struct {
+	// Tag is the name of the box in the FFD_DEFINITION option.
+	Tag string
+	// Degree is the polynomial degree in each direction. There are
+	// Degree[d]+1 control points along direction d.
+	Degree []int
+	// Lo and Hi are opposite corners of the box.
+	Lo, Hi []float64
+	// Control holds the control point locations. The index of control point
+	// (i, j, k) is given by Index.
+	Control [][]float64
+	// Points are the marker points inside the box.
+	Points []FFDPoint
+}
+
+// FFDPoint is a marker point inside an FFD box, with its parametric
+// coordinates in the box, each between 0 and 1.
+type FFDPoint struct {
+	Marker string
+	Point  PointID
+	Param  []float64
+}
+
+// NewFFDBox builds the FFD box with the given tag and degrees around the points
+// of the markers. The box is the bounding box of the points, grown by margin
+// times its size on every side.
+func (s *SU2) NewFFDBox(tag string, markers []string, degree []int, margin float64) (*FFDBox, error) {
+	if len(degree) != s.Dim {
+		return nil, fmt.Errorf("ffd: %d degrees in %dD", len(degree), s.Dim)
+	}
+	for _, d := range degree {
+		if d < 1 {
+			return nil, fmt.Errorf("ffd: bad degree %d", d)
+		}
+	}
+	if margin < 0 {
+		return nil, fmt.Errorf("ffd: negative margin %v", margin)
+	}
+	var x [][]float64
+	for _, tag := range markers {
+		marker := s.Marker(tag)
+		if marker == nil {
+			return nil, fmt.Errorf("ffd: no marker %q", tag)
+		}
+		for _, id := range marker.PointIds() {
+			x = append(x, s.Points[id].Location)
+		}
+	}
+	if len(x) == 0 {
+		return nil, errors.New("ffd: no marker points")
+	}
+	lo, hi := bounds(x)
+	diag := distance(lo, hi)
+	for d := range lo {
+		size := hi[d] - lo[d]
+		if size == 0 {
+			// Flat markers still need a box with some thickness.
+			size = diag
+		}
+		lo[d] -= margin * size
+		hi[d] += margin * size
+		if !(hi[d] > lo[d]) {
+			return nil, errors.New("ffd: markers have no extent")
+		}
+	}
+
+	b := &FFDBox{
+		Tag:    tag,
+		Degree: append([]int(nil), degree...),
+		Lo:     lo,
+		Hi:     hi,
+	}
+	b.Control = make([][]float64, b.NumControl())
+	ijk := make([]int, 3)
+	for ijk[0] = 0; ijk[0] <= degree[0]; ijk[0]++ {
+		for ijk[1] = 0; ijk[1] <= degree[1]; ijk[1]++ {
+			for ijk[2] = 0; ijk[2] <= b.degree(2); ijk[2]++ {
+				c := make([]float64, s.Dim)
+				for d := range c {
+					c[d] = lo[d] + float64(ijk[d])/float64(degree[d])*(hi[d]-lo[d])
+				}
+				b.Control[b.Index(ijk[0], ijk[1], ijk[2])] = c
+			}
+		}
+	}
+	// The lattice is uniform, so the parametric coordinates are linear in the
+	// location.
+	for _, tag := range markers {
+		for _, id := range s.Marker(tag).PointIds() {
+			loc := s.Points[id].Location
+			param := make([]float64, s.Dim)
+			for d := range param {
+				param[d] = (loc[d] - lo[d]) / (hi[d] - lo[d])
+			}
+			b.Points = append(b.Points, FFDPoint{Marker: tag, Point: id, Param: param})
+		}
+	}
+	return b, nil
+}
+
+// degree returns the degree in direction d, which is zero for the third
+// direction of 2D boxes.
+func (b *FFDBox) degree(d int) int {
+	if d >= len(b.Degree) {
+		return 0
+	}
+	return b.Degree[d]
+}
+
+// NumControl returns the number of control points.
+func (b *FFDBox) NumControl() int {
+	return (b.degree(0) + 1) * (b.degree(1) + 1) * (b.degree(2) + 1)
+}
+
+// Index returns the index in Control of control point (i, j, k). As in SU2, k
+// varies fastest. k is zero in 2D.
+func (b *FFDBox) Index(i, j, k int) int {
+	return (i*(b.degree(1)+1)+j)*(b.degree(2)+1) + k
+}
+
+// bernstein returns the Bernstein polynomials of degree n at t.
+func bernstein(n int, t float64) []float64 {
+	p := make([]float64, n+1)
+	binom := 1.0
+	for i := 0; i <= n; i++ {
+		p[i] = binom * math.Pow(t, float64(i)) * math.Pow(1-t, float64(n-i))
+		binom = binom * float64(n-i) / float64(i+1)
+	}
+	return p
+}
+
+// Evaluate returns the displacements of Points when the control points are
+// moved by disp, where disp[i] is the displacement of Control[i].
+func (b *FFDBox) Evaluate(disp [][]float64) ([][]float64, error) {
+	if len(disp) != len(b.Control) {
+		return nil, fmt.Errorf("ffd: %d displacements for %d control points", len(disp), len(b.Control))
+	}
+	dim := len(b.Degree)
+	for i, d := range disp {
+		if len(d) != dim {
+			return nil, fmt.Errorf("ffd: control point %d: displacement of length %d", i, len(d))
+		}
+	}
+	out := make([][]float64, len(b.Points))
+	for p, point := range b.Points {
+		var basis [3][]float64
+		for d := 0; d < 3; d++ {
+			t := 0.0
+			if d < dim {
+				t = point.Param[d]
+			}
+			basis[d] = bernstein(b.degree(d), t)
+		}
+		u := make([]float64, dim)
+		for i, bi := range basis[0] {
+			for j, bj := range basis[1] {
+				for k, bk := range basis[2] {
+					addScaled(u, disp[b.Index(i, j, k)], bi*bj*bk)
+				}
+			}
+		}
+		out[p] = u
+	}
+	return out, nil
+}
+
+// MarkerDisplacements returns the displacements of the points of the markers
+// of the box for Deform, when the control points are moved by disp.
+func (b *FFDBox) MarkerDisplacements(disp [][]float64) (map[string][][]float64, error) {
+	u, err := b.Evaluate(disp)
+	if err != nil {
+		return nil, err
+	}
+	// Points are stored in the order of Marker.PointIds.
+	m := make(map[string][][]float64)
+	for i, point := range b.Points {
+		m[point.Marker] = append(m[point.Marker], u[i])
+	}
+	return m, nil
+}
+
+// WriteFFD writes the FFD boxes in the format SU2 expects at the end of a mesh
+// file, after the blocks written by WriteTo. All boxes are at level 0.
+func (s *SU2) WriteFFD(w io.Writer, boxes []*FFDBox) (n int64, err error) {
+	cw := &countWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'e', 15, 64)
+	}
+	buf.WriteString("FFD_NBOX= " + strconv.Itoa(len(boxes)) + "\n")
+	buf.WriteString("FFD_NLEVEL= 1\n")
+	for _, b := range boxes {
+		dim := len(b.Degree)
+		if dim != s.Dim {
+			return cw.n, fmt.Errorf("ffd: %dD box %s in %dD mesh", dim, b.Tag, s.Dim)
+		}
+		buf.WriteString("FFD_TAG= " + b.Tag + "\n")
+		buf.WriteString("FFD_LEVEL= 0\n")
+		for d, name := range []string{"I", "J", "K"}[:dim] {
+			buf.WriteString("FFD_DEGREE_" + name + "= " + strconv.Itoa(b.Degree[d]) + "\n")
+		}
+		buf.WriteString("FFD_BLENDING= BEZIER\n")
+		buf.WriteString("FFD_PARENTS= 0\n")
+		buf.WriteString("FFD_CHILDREN= 0\n")
+
+		// The corners are ordered like the vertices of a quadrilateral or
+		// hexahedron.
+		corners := 1 << uint(dim)
+		buf.WriteString("FFD_CORNER_POINTS= " + strconv.Itoa(corners) + "\n")
+		for c := 0; c < corners; c++ {
+			sel := []bool{(c+1)/2%2 == 1, c/2%2 == 1, c/4 == 1}
+			for d := 0; d < dim; d++ {
+				if d > 0 {
+					buf.WriteByte('\t')
+				}
+				v := b.Lo[d]
+				if sel[d] {
+					v = b.Hi[d]
+				}
+				buf.WriteString(format(v))
+			}
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteString("FFD_CONTROL_POINTS= " + strconv.Itoa(len(b.Control)) + "\n")
+		for i := 0; i <= b.degree(0); i++ {
+			for j := 0; j <= b.degree(1); j++ {
+				for k := 0; k <= b.degree(2); k++ {
+					buf.WriteString(strconv.Itoa(i) + "\t" + strconv.Itoa(j) + "\t" + strconv.Itoa(k))
+					for _, v := range b.Control[b.Index(i, j, k)] {
+						buf.WriteString("\t" + format(v))
+					}
+					buf.WriteByte('\n')
+				}
+			}
+		}
+
+		buf.WriteString("FFD_SURFACE_POINTS= " + strconv.Itoa(len(b.Points)) + "\n")
+		for _, p := range b.Points {
+			buf.WriteString(p.Marker + "\t" + strconv.Itoa(int(p.Point)))
+			for d := 0; d < 3; d++ {
+				v := 0.0
+				if d < dim {
+					v = p.Param[d]
+				}
+				buf.WriteString("\t" + format(v))
+			}
+			buf.WriteByte('\n')
+		}
+	}
+	err = buf.Flush()
+	return cw.n, err
+}
@@ -0,0 +1,101 @@
+package mesh
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFFDBox(t *testing.T) {
+	s, err := Channel{Length: 4, Height: 1, Half: true, NX: 21, NY: 6}.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.NewFFDBox("MAIN_BOX", []string{"wall"}, []int{5, 1}, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Control) != 12 || len(b.Points) != 21 {
+		t.Fatalf("size mismatch: %d control points and %d surface points", len(b.Control), len(b.Points))
+	}
+	if !closeTo(b.Lo, []float64{-0.4, -0.4}, 1e-14) || !closeTo(b.Hi, []float64{4.4, 0.4}, 1e-14) {
+		t.Errorf("box mismatch: %v %v", b.Lo, b.Hi)
+	}
+
+	// Bernstein polynomials reproduce linear displacements.
+	linear := func(x []float64) []float64 {
+		return []float64{0.1*x[0] - 0.2*x[1], 0.3*x[0] + 0.05}
+	}
+	disp := make([][]float64, len(b.Control))
+	for i, c := range b.Control {
+		disp[i] = linear(c)
+	}
+	u, err := b.Evaluate(disp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range b.Points {
+		if want := linear(s.Points[p.Point].Location); !closeTo(u[i], want, 1e-14) {
+			t.Errorf("point %d: displacement mismatch. Expected %v, found %v", p.Point, want, u[i])
+		}
+	}
+
+	// Moving one control point moves the points by its basis function.
+	for i := range disp {
+		disp[i] = []float64{0, 0}
+	}
+	disp[b.Index(2, 1, 0)] = []float64{0, 1}
+	markerDisp, err := b.MarkerDisplacements(disp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := s.Marker("wall").PointIds()
+	for i, id := range ids {
+		x := s.Points[id].Location
+		tu := (x[0] + 0.4) / 4.8
+		tv := (x[1] + 0.4) / 0.8
+		want := 10 * math.Pow(tu, 2) * math.Pow(1-tu, 3) * tv
+		if got := markerDisp["wall"][i]; math.Abs(got[1]-want) > 1e-14 || got[0] != 0 {
+			t.Errorf("point %d: displacement mismatch. Expected %v, found %v", id, want, got)
+		}
+	}
+	r, err := s.Deform(markerDisp, Deformation{Method: RBF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Inverted) != 0 {
+		t.Errorf("inverted elements: %v", r)
+	}
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.WriteFFD(&buf, []*FFDBox{b}); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.String()
+	for _, line := range []string{
+		"FFD_NBOX= 1\n", "FFD_TAG= MAIN_BOX\n", "FFD_DEGREE_I= 5\n", "FFD_DEGREE_J= 1\n",
+		"FFD_CORNER_POINTS= 4\n", "FFD_CONTROL_POINTS= 12\n", "FFD_SURFACE_POINTS= 21\n",
+	} {
+		if !strings.Contains(file, line) {
+			t.Errorf("missing %q", line)
+		}
+	}
+	if strings.Contains(file, "FFD_DEGREE_K") {
+		t.Errorf("K degree in 2D")
+	}
+	// The mesh is still readable with the FFD blocks.
+	if _, err := (&SU2{}).ReadFrom(strings.NewReader(file)); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := s.NewFFDBox("BOX", []string{"wall"}, []int{5}, 0.1); err == nil {
+		t.Errorf("no error for wrong number of degrees")
+	}
+	if _, err := b.Evaluate(disp[1:]); err == nil {
+		t.Errorf("no error for missing displacements")
+	}
+}
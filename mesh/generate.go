@@ -0,0 +1,265 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Stretching is the kind of point distribution along a line.
+type Stretching int
+
+const (
+	Uniform   Stretching = iota
+	Geometric            // Cell sizes grow by a constant ratio
+	Tanh                 // Hyperbolic tangent clustering
+)
+
+// Spacing describes the distribution of points along a line, clustered toward
+// the start of the line.
+type Spacing struct {
+	Stretching Stretching
+	// Ratio is the growth ratio of neighboring cells for Geometric spacing and
+	// the stretching parameter for Tanh spacing. It is ignored if FirstCell is
+	// set.
+	Ratio float64
+	// FirstCell is the target size of the first cell. If it is nonzero the
+	// ratio is found to match it.
+	FirstCell float64
+}
+
+// Distribute returns n points from 0 to length clustered toward 0.
+func (sp Spacing) Distribute(n int, length float64) ([]float64, error) {
+	return sp.distribute(n, length, false)
+}
+
+// distribute returns n points from 0 to length. If symmetric is true the points
+// are clustered toward both ends.
+func (sp Spacing) distribute(n int, length float64, symmetric bool) ([]float64, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("spacing: need at least 2 points, have %d", n)
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("spacing: bad length %v", length)
+	}
+	if sp.FirstCell < 0 {
+		return nil, fmt.Errorf("spacing: bad first cell size %v", sp.FirstCell)
+	}
+	cells := n - 1
+	x := make([]float64, n)
+	switch sp.Stretching {
+	case Uniform:
+		for i := range x {
+			x[i] = length * float64(i) / float64(cells)
+		}
+	case Geometric:
+		// The size of cell i is h r^k[i], with k counting from the nearest
+		// clustered end.
+		k := make([]float64, cells)
+		for i := range k {
+			k[i] = float64(i)
+			if symmetric && cells-1-i < i {
+				k[i] = float64(cells - 1 - i)
+			}
+		}
+		total := func(r float64) float64 {
+			var sum float64
+			for _, v := range k {
+				sum += math.Pow(r, v)
+			}
+			return sum
+		}
+		r := sp.Ratio
+		if sp.FirstCell != 0 {
+			var err error
+			r, err = solveIncreasing(total, length/sp.FirstCell, 1)
+			if err != nil {
+				return nil, fmt.Errorf("spacing: first cell %v: %v", sp.FirstCell, err)
+			}
+		}
+		if r <= 0 {
+			return nil, fmt.Errorf("spacing: bad ratio %v", r)
+		}
+		h := length / total(r)
+		for i, v := range k {
+			x[i+1] = x[i] + h*math.Pow(r, v)
+		}
+	case Tanh:
+		at := func(delta, xi float64) float64 {
+			if delta == 0 {
+				return length * xi
+			}
+			if symmetric {
+				return length / 2 * (1 + math.Tanh(delta*(2*xi-1))/math.Tanh(delta))
+			}
+			return length * (1 + math.Tanh(delta*(xi-1))/math.Tanh(delta))
+		}
+		delta := sp.Ratio
+		if sp.FirstCell != 0 {
+			if sp.FirstCell >= length/float64(cells) {
+				return nil, fmt.Errorf("spacing: first cell %v is not smaller than uniform", sp.FirstCell)
+			}
+			// The first cell shrinks as delta grows, so solve for the inverse.
+			var err error
+			delta, err = solveIncreasing(func(d float64) float64 {
+				return 1 / at(d, 1/float64(cells))
+			}, 1/sp.FirstCell, 0)
+			if err != nil {
+				return nil, fmt.Errorf("spacing: first cell %v: %v", sp.FirstCell, err)
+			}
+		}
+		if delta < 0 {
+			return nil, fmt.Errorf("spacing: bad stretching %v", delta)
+		}
+		for i := range x {
+			x[i] = at(delta, float64(i)/float64(cells))
+		}
+	default:
+		return nil, fmt.Errorf("spacing: unknown stretching %d", sp.Stretching)
+	}
+	// Remove the rounding error at the end
+	x[0] = 0
+	x[cells] = length
+	return x, nil
+}
+
+// solveIncreasing finds the positive x where the increasing function f equals
+// target by bisection, starting from the guess x0.
+func solveIncreasing(f func(float64) float64, target, x0 float64) (float64, error) {
+	lo, hi := 0.0, math.Max(x0, 1)
+	for f(hi) < target {
+		lo = hi
+		hi *= 2
+		if hi > 1e6 {
+			return 0, errors.New("no solution")
+		}
+	}
+	if f(lo) > target {
+		return 0, errors.New("no solution")
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if f(mid) < target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, nil
+}
+
+// FlatPlate describes a structured quadrilateral mesh around a flat plate at
+// y = 0 that starts at x = 0, with an inflow region upstream of the leading
+// edge. The markers are inlet, outlet, farfield, symmetry ahead of the plate and
+// wall along it.
+type FlatPlate struct {
+	Inflow float64 // Length of the region upstream of the leading edge
+	Length float64 // Length of the plate
+	Height float64 // Height of the domain
+
+	// Number of points upstream of the leading edge, along the plate and
+	// normal to the wall. The leading edge point is counted in both NInflow
+	// and NPlate.
+	NInflow, NPlate, NNormal int
+
+	// Streamwise is the spacing in x on either side of the leading edge, and
+	// Normal the spacing from the wall.
+	Streamwise Spacing
+	Normal     Spacing
+}
+
+// Mesh returns the flat plate mesh. Points and elements are numbered with x
+// varying fastest.
+func (f FlatPlate) Mesh() (*SU2, error) {
+	if f.Inflow < 0 || (f.Inflow > 0) != (f.NInflow > 1) {
+		return nil, fmt.Errorf("flat plate: inflow length %v with %d points", f.Inflow, f.NInflow)
+	}
+	plate, err := f.Streamwise.Distribute(f.NPlate, f.Length)
+	if err != nil {
+		return nil, fmt.Errorf("flat plate: %v", err)
+	}
+	var x []float64
+	if f.Inflow > 0 {
+		inflow, err := f.Streamwise.Distribute(f.NInflow, f.Inflow)
+		if err != nil {
+			return nil, fmt.Errorf("flat plate: %v", err)
+		}
+		for i := len(inflow) - 1; i > 0; i-- {
+			x = append(x, -inflow[i])
+		}
+	}
+	x = append(x, plate...)
+	y, err := f.Normal.Distribute(f.NNormal, f.Height)
+	if err != nil {
+		return nil, fmt.Errorf("flat plate: %v", err)
+	}
+
+	ni := len(x)
+	patches := []Patch{
+		{Face: IMin, Tag: "inlet"},
+		{Face: IMax, Tag: "outlet"},
+		{Face: JMax, Tag: "farfield"},
+	}
+	lead := ni - len(plate)
+	if lead > 0 {
+		patches = append(patches, Patch{Face: JMin, Tag: "symmetry", Range: [2][2]int{{0, lead}}})
+	}
+	patches = append(patches, Patch{Face: JMin, Tag: "wall", Range: [2][2]int{{lead, ni - 1}}})
+	return structuredMesh([]*block{tensorBlock(x, y)}, patches, 0)
+}
+
+// Channel describes a structured quadrilateral mesh of a channel from x = 0 to
+// Length and y = 0 to Height. The markers are inlet, outlet and wall. If Half is
+// true only the lower half of the channel is meshed, and the upper boundary is
+// the symmetry marker.
+type Channel struct {
+	Length float64
+	Height float64
+	Half   bool
+
+	// Number of points along and across the channel
+	NX, NY int
+
+	// Normal is the spacing from the walls. Points are uniform along the
+	// channel.
+	Normal Spacing
+}
+
+// Mesh returns the channel mesh. Points and elements are numbered with x
+// varying fastest.
+func (c Channel) Mesh() (*SU2, error) {
+	x, err := Spacing{}.Distribute(c.NX, c.Length)
+	if err != nil {
+		return nil, fmt.Errorf("channel: %v", err)
+	}
+	height := c.Height
+	if c.Half {
+		height /= 2
+	}
+	y, err := c.Normal.distribute(c.NY, height, !c.Half)
+	if err != nil {
+		return nil, fmt.Errorf("channel: %v", err)
+	}
+	patches := []Patch{
+		{Face: IMin, Tag: "inlet"},
+		{Face: IMax, Tag: "outlet"},
+		{Face: JMin, Tag: "wall"},
+	}
+	if c.Half {
+		patches = append(patches, Patch{Face: JMax, Tag: "symmetry"})
+	} else {
+		patches = append(patches, Patch{Face: JMax, Tag: "wall"})
+	}
+	return structuredMesh([]*block{tensorBlock(x, y)}, patches, 0)
+}
+
+// tensorBlock returns the 2D block with the points at all pairs of x and y.
+func tensorBlock(x, y []float64) *block {
+	b := &block{ni: len(x), nj: len(y), nk: 1}
+	for _, yv := range y {
+		for _, xv := range x {
+			b.x = append(b.x, []float64{xv, yv})
+		}
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpacing(t *testing.T) {
+	for _, sp := range []Spacing{
+		{Stretching: Geometric, FirstCell: 2e-6},
+		{Stretching: Geometric, FirstCell: 0.02},
+		{Stretching: Tanh, FirstCell: 2e-6},
+	} {
+		x, err := sp.Distribute(97, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(x[1]-sp.FirstCell)/sp.FirstCell > 1e-8 || x[96] != 1 {
+			t.Errorf("Spacing %v mismatch. Found first cell %v and end %v", sp, x[1], x[96])
+		}
+		for i := 1; i < len(x); i++ {
+			if x[i] <= x[i-1] {
+				t.Fatalf("Spacing %v not increasing at %d", sp, i)
+			}
+		}
+	}
+
+	x, err := Spacing{Stretching: Geometric, Ratio: 1.2}.Distribute(5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < len(x); i++ {
+		if r := (x[i] - x[i-1]) / (x[i-1] - x[i-2]); math.Abs(r-1.2) > 1e-12 {
+			t.Errorf("Ratio mismatch. Expected %v, found %v", 1.2, r)
+		}
+	}
+
+	for _, n := range []int{6, 7} {
+		x, err := Spacing{Stretching: Geometric, FirstCell: 0.01}.distribute(n, 2, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range x {
+			if math.Abs(x[i]+x[n-1-i]-2) > 1e-12 {
+				t.Errorf("Symmetric spacing mismatch: %v", x)
+				break
+			}
+		}
+	}
+
+	if _, err := (Spacing{Stretching: Tanh, FirstCell: 0.5}).Distribute(5, 1); err == nil {
+		t.Errorf("no error for tanh first cell larger than uniform")
+	}
+}
+
+func TestFlatPlate(t *testing.T) {
+	s, err := FlatPlate{
+		Inflow:     0.33333,
+		Length:     2,
+		Height:     1,
+		NInflow:    25,
+		NPlate:     113,
+		NNormal:    97,
+		Streamwise: Spacing{Stretching: Geometric, FirstCell: 4e-3},
+		Normal:     Spacing{Stretching: Geometric, FirstCell: 2e-6},
+	}.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Same layout as mesh_flatplate_turb_137x97.su2
+	if len(s.Points) != 137*97 || len(s.Elements) != 136*96 {
+		t.Fatalf("Size mismatch. Found %d points and %d elements", len(s.Points), len(s.Elements))
+	}
+	if x := s.Points[24].Location; x[0] != 0 || x[1] != 0 {
+		t.Errorf("Leading edge mismatch. Found %v", x)
+	}
+	if y := s.Points[137].Location[1]; math.Abs(y-2e-6) > 1e-12 {
+		t.Errorf("First cell mismatch. Expected %v, found %v", 2e-6, y)
+	}
+	for _, elem := range s.Elements {
+		if s.Volume(elem) <= 0 {
+			t.Fatalf("inverted element %v", elem)
+		}
+	}
+	for _, test := range []struct {
+		tag   string
+		count int
+	}{{"farfield", 136}, {"inlet", 96}, {"outlet", 96}, {"symmetry", 24}, {"wall", 112}} {
+		marker := s.Marker(test.tag)
+		if marker == nil || len(marker.Elements) != test.count {
+			t.Errorf("Marker %s mismatch: %v", test.tag, marker)
+		}
+	}
+}
+
+func TestChannel(t *testing.T) {
+	for _, half := range []bool{false, true} {
+		s, err := Channel{
+			Length: 4,
+			Height: 1,
+			Half:   half,
+			NX:     9,
+			NY:     10,
+			Normal: Spacing{Stretching: Tanh, Ratio: 2},
+		}.Mesh()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var area float64
+		for _, elem := range s.Elements {
+			area += s.Volume(elem)
+		}
+		want := 4.0
+		tags := map[string]int{"inlet": 9, "outlet": 9, "wall": 16}
+		if half {
+			want = 2
+			tags["symmetry"] = 8
+			tags["wall"] = 8
+		}
+		if math.Abs(area-want) > 1e-12 {
+			t.Errorf("Area mismatch. Expected %v, found %v", want, area)
+		}
+		if len(s.Markers) != len(tags) {
+			t.Errorf("Marker mismatch. Expected %v, found %v", len(tags), len(s.Markers))
+		}
+		for tag, count := range tags {
+			marker := s.Marker(tag)
+			if marker == nil || len(marker.Elements) != count {
+				t.Errorf("Marker %s mismatch: %v", tag, marker)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// coords returns the locations of the vertices of the element.
+func (s *SU2) coords(e *Element) [][]float64 {
+	x := make([][]float64, len(e.VertexIds))
+	for i, id := range e.VertexIds {
+		x[i] = s.Points[id].Location
+	}
+	return x
+}
+
+// Centroid returns the centroid of the element. As in SU2, it is the average of
+// the vertex locations.
+func (s *SU2) Centroid(e *Element) []float64 {
+	return average(s.coords(e))
+}
+
+// Volume returns the volume of the element. It is the length of a line, the
+// area of a triangle or quadrilateral and the volume of a 3D element. The
+// volume is signed for elements with the same dimension as the mesh, and is
+// negative when the element is inverted. The area of a triangle or
+// quadrilateral in 3D, such as a marker element, is always positive.
+func (s *SU2) Volume(e *Element) float64 {
+	x := s.coords(e)
+	switch e.Type.Dim() {
+	case 1:
+		return distance(x[0], x[1])
+	case 2:
+		if len(x[0]) == 2 {
+			// Shoelace formula
+			var area float64
+			for i := range x {
+				j := (i + 1) % len(x)
+				area += x[i][0]*x[j][1] - x[j][0]*x[i][1]
+			}
+			return area / 2
+		}
+		return norm(faceNormal(x))
+	case 3:
+		// Divergence theorem with the faces relative to the centroid
+		c := average(x)
+		var vol float64
+		for _, face := range e.Type.Faces() {
+			fx := make([][]float64, len(face))
+			for i, local := range face {
+				fx[i] = x[local]
+			}
+			vol += dot(sub(average(fx), c), faceNormal(fx))
+		}
+		return vol / 3
+	}
+	panic(fmt.Sprintf("mesh: volume of %v element", e.Type))
+}
+
+// Normal returns the normal of a face element, which is a line in 2D or a
+// triangle or quadrilateral in 3D. The magnitude of the normal is the length or
+// area of the element. In 2D the normal points to the right of the line from
+// the first to the second vertex, and in 3D it follows the right-hand rule.
+func (s *SU2) Normal(e *Element) []float64 {
+	return faceNormal(s.coords(e))
+}
+
+// Area returns the length or area of a face element.
+func (s *SU2) Area(e *Element) float64 {
+	return norm(s.Normal(e))
+}
+
+// FaceNormals returns the normals of the faces of the element in the order of
+// e.Type.Faces(). The normals point out of the element when it is not inverted,
+// and their magnitude is the face area.
+func (s *SU2) FaceNormals(e *Element) [][]float64 {
+	x := s.coords(e)
+	faces := e.Type.Faces()
+	normals := make([][]float64, len(faces))
+	for i, face := range faces {
+		fx := make([][]float64, len(face))
+		for j, local := range face {
+			fx[j] = x[local]
+		}
+		normals[i] = faceNormal(fx)
+	}
+	return normals
+}
+
+// MarkerNormals returns the normals of the marker elements, pointing out of the
+// domain. The magnitude of each normal is the area of the marker element. An
+// error is returned if a marker element is not a face of a volume element.
+func (s *SU2) MarkerNormals(m *Marker) ([][]float64, error) {
+	faces := s.faces()
+	normals := make([][]float64, len(m.Elements))
+	for i := range m.Elements {
+		elem := &m.Elements[i]
+		refs := faces[newFaceKey(elem.VertexIds)]
+		if len(refs) == 0 {
+			return nil, fmt.Errorf("marker %s element %d is not a face of the mesh", m.Tag, i)
+		}
+		owner := s.Elements[refs[0].Element]
+		normal := s.Normal(elem)
+		// The normal points out of the domain if it points away from the
+		// center of the element that owns the face.
+		if dot(normal, sub(s.Centroid(elem), s.Centroid(owner))) < 0 {
+			for j := range normal {
+				normal[j] = -normal[j]
+			}
+		}
+		normals[i] = normal
+	}
+	return normals, nil
+}
+
+// faceKey identifies a face by its sorted vertex ids. Unused entries are -1.
+type faceKey [4]PointID
+
+func newFaceKey(ids []PointID) faceKey {
+	key := faceKey{-1, -1, -1, -1}
+	copy(key[:], ids)
+	sort.Slice(key[:len(ids)], func(i, j int) bool { return key[i] < key[j] })
+	return key
+}
+
+// faceRef is a face of an element, where Face indexes Element.Type.Faces().
+type faceRef struct {
+	Element ElementID
+	Face    int
+}
+
+// faces returns the faces of all of the elements, keyed by their vertices.
+// Interior faces are shared by two elements and boundary faces belong to one.
+func (s *SU2) faces() map[faceKey][]faceRef {
+	return s.facesOf(nil)
+}
+
+// facesOf is like faces, but only includes the elements for which use is true,
+// or all of them if use is nil.
+func (s *SU2) facesOf(use []bool) map[faceKey][]faceRef {
+	faces := make(map[faceKey][]faceRef)
+	ids := make([]PointID, 4)
+	for i, elem := range s.Elements {
+		if use != nil && !use[i] {
+			continue
+		}
+		for j, face := range elem.Type.Faces() {
+			ids = ids[:len(face)]
+			for k, local := range face {
+				ids[k] = elem.VertexIds[local]
+			}
+			key := newFaceKey(ids)
+			faces[key] = append(faces[key], faceRef{Element: ElementID(i), Face: j})
+		}
+	}
+	return faces
+}
+
+// faceNormal returns the area-weighted normal of the face with the given
+// vertex locations.
+func faceNormal(x [][]float64) []float64 {
+	switch len(x) {
+	case 2:
+		return []float64{x[1][1] - x[0][1], x[0][0] - x[1][0]}
+	case 3:
+		n := cross(sub(x[1], x[0]), sub(x[2], x[0]))
+		return scale(n, 0.5)
+	case 4:
+		n := cross(sub(x[2], x[0]), sub(x[3], x[1]))
+		return scale(n, 0.5)
+	}
+	panic("mesh: bad face size")
+}
+
+// distance returns the Euclidean distance between two locations.
+func distance(x, y []float64) float64 {
+	return norm(sub(x, y))
+}
+
+func average(x [][]float64) []float64 {
+	avg := make([]float64, len(x[0]))
+	for _, v := range x {
+		for i := range avg {
+			avg[i] += v[i]
+		}
+	}
+	return scale(avg, 1/float64(len(x)))
+}
+
+func sub(x, y []float64) []float64 {
+	d := make([]float64, len(x))
+	for i := range x {
+		d[i] = x[i] - y[i]
+	}
+	return d
+}
+
+func scale(x []float64, f float64) []float64 {
+	for i := range x {
+		x[i] *= f
+	}
+	return x
+}
+
+func dot(x, y []float64) float64 {
+	var sum float64
+	for i := range x {
+		sum += x[i] * y[i]
+	}
+	return sum
+}
+
+func norm(x []float64) float64 {
+	return math.Sqrt(dot(x, x))
+}
+
+func cross(x, y []float64) []float64 {
+	return []float64{
+		x[1]*y[2] - x[2]*y[1],
+		x[2]*y[0] - x[0]*y[2],
+		x[0]*y[1] - x[1]*y[0],
+	}
+}
@@ -0,0 +1,80 @@
+package mesh
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func readString(t *testing.T, file string) *SU2 {
+	s := &SU2{}
+	_, err := s.ReadFrom(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func closeTo(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestVolume(t *testing.T) {
+	s := readString(t, hybrid2D)
+	for i, want := range []float64{0.5, 0.25, 0.25} {
+		if v := s.Volume(s.Elements[i]); math.Abs(v-want) > 1e-14 {
+			t.Errorf("2D element %d: volume mismatch. Expected %v, found %v", i, want, v)
+		}
+	}
+	s = readString(t, hybrid3D)
+	for i, want := range []float64{1, 1.0 / 6, 1.0 / 12, 0.5} {
+		if v := s.Volume(s.Elements[i]); math.Abs(v-want) > 1e-14 {
+			t.Errorf("3D element %d: volume mismatch. Expected %v, found %v", i, want, v)
+		}
+		// The faces of an element are closed
+		sum := make([]float64, 3)
+		for _, n := range s.FaceNormals(s.Elements[i]) {
+			for j := range sum {
+				sum[j] += n[j]
+			}
+		}
+		if !closeTo(sum, []float64{0, 0, 0}, 1e-14) {
+			t.Errorf("3D element %d: face normals don't sum to zero: %v", i, sum)
+		}
+	}
+	if c := s.Centroid(s.Elements[1]); !closeTo(c, []float64{0.5, 0.5, 1.1}, 1e-14) {
+		t.Errorf("Centroid mismatch. Expected %v, found %v", []float64{0.5, 0.5, 1.1}, c)
+	}
+}
+
+func TestMarkerNormals(t *testing.T) {
+	s := readString(t, hybrid2D)
+	// Reverse one of the elements so it points into the domain.
+	lower := s.Marker("lower")
+	lower.Elements[1].VertexIds = []PointID{2, 1}
+	for _, test := range []struct {
+		tag  string
+		want [][]float64
+	}{
+		{"lower", [][]float64{{0, -0.5}, {0, -0.5}}},
+		{"upper", [][]float64{{0, 0.5}, {0, 0.5}}},
+	} {
+		normals, err := s.MarkerNormals(s.Marker(test.tag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range normals {
+			if !closeTo(normals[i], test.want[i], 1e-14) {
+				t.Errorf("%s element %d: normal mismatch. Expected %v, found %v", test.tag, i, test.want[i], normals[i])
+			}
+		}
+	}
+}
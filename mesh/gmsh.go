@@ -0,0 +1,509 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gmshTypes maps the Gmsh element types to VTK types. Gmsh point elements
+// (type 15) are ignored.
+var gmshTypes = map[int]VTKType{
+	1: Line,
+	2: Triangle,
+	3: Quadrilateral,
+	4: Tetrahedron,
+	5: Hexahedron,
+	6: Prism,
+	7: Pyramid,
+}
+
+// gmshPrismOrder reorders the nodes of a Gmsh prism, whose first triangle has
+// its normal pointing towards the second, into the VTK order.
+var gmshPrismOrder = []int{0, 2, 1, 3, 5, 4}
+
+type gmshElement struct {
+	Type      VTKType
+	Physicals []int
+	Nodes     []int
+}
+
+// gmshMesh holds the contents of a Gmsh file.
+type gmshMesh struct {
+	version  float64
+	names    map[[2]int]string // Physical names by dimension and tag
+	entities map[[2]int][]int  // Physical tags of the entities by dimension and tag
+	nodes    map[int][]float64
+	elements []gmshElement
+}
+
+// gmshScanner reads the non-empty lines of a Gmsh file.
+type gmshScanner struct {
+	*bufio.Scanner
+}
+
+func (g gmshScanner) line() (string, error) {
+	for g.Scan() {
+		str := strings.TrimSpace(g.Text())
+		if len(str) != 0 {
+			return str, nil
+		}
+	}
+	if err := g.Err(); err != nil {
+		return "", err
+	}
+	return "", io.ErrUnexpectedEOF
+}
+
+func (g gmshScanner) ints() ([]int, error) {
+	str, err := g.line()
+	if err != nil {
+		return nil, err
+	}
+	strs := strings.Fields(str)
+	v := make([]int, len(strs))
+	for i, s := range strs {
+		v[i], err = strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (g gmshScanner) floats() ([]float64, error) {
+	str, err := g.line()
+	if err != nil {
+		return nil, err
+	}
+	strs := strings.Fields(str)
+	v := make([]float64, len(strs))
+	for i, s := range strs {
+		v[i], err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// ReadGmsh reads an ASCII Gmsh mesh in the version 2 or 4 format. The
+// elements of the highest dimension become the mesh elements, and the
+// elements one dimension lower that belong to a physical group become the
+// elements of a marker named after the group. Unnamed groups are tagged with
+// their number. Only the points used by the mesh elements are kept, in the
+// order of their Gmsh tags.
+func ReadGmsh(r io.Reader) (*SU2, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	g := gmshScanner{scanner}
+	m := &gmshMesh{
+		names:    make(map[[2]int]string),
+		entities: make(map[[2]int][]int),
+		nodes:    make(map[int][]float64),
+	}
+	for {
+		section, err := g.line()
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(section, "$") || strings.HasPrefix(section, "$End") {
+			return nil, fmt.Errorf("gmsh: unexpected line %q", section)
+		}
+		switch section {
+		case "$MeshFormat":
+			err = m.readFormat(g)
+		case "$PhysicalNames":
+			err = m.readNames(g)
+		case "$Entities":
+			err = m.readEntities(g)
+		case "$Nodes":
+			if m.version >= 4 {
+				err = m.readNodes4(g)
+			} else {
+				err = m.readNodes2(g)
+			}
+		case "$Elements":
+			if m.version >= 4 {
+				err = m.readElements4(g)
+			} else {
+				err = m.readElements2(g)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gmsh: %s: %v", section, err)
+		}
+		// Skip to the end of the section
+		end := "$End" + section[1:]
+		for {
+			str, err := g.line()
+			if err != nil {
+				return nil, fmt.Errorf("gmsh: no %s", end)
+			}
+			if str == end {
+				break
+			}
+		}
+	}
+	if m.version == 0 {
+		return nil, errors.New("gmsh: no $MeshFormat")
+	}
+	return m.su2()
+}
+
+func (m *gmshMesh) readFormat(g gmshScanner) error {
+	str, err := g.line()
+	if err != nil {
+		return err
+	}
+	strs := strings.Fields(str)
+	if len(strs) < 2 {
+		return errors.New("bad format line")
+	}
+	m.version, err = strconv.ParseFloat(strs[0], 64)
+	if err != nil {
+		return err
+	}
+	if m.version < 2 || m.version >= 5 {
+		return fmt.Errorf("version %s not supported", strs[0])
+	}
+	if strs[1] != "0" {
+		return errors.New("binary files not supported")
+	}
+	return nil
+}
+
+func (m *gmshMesh) readNames(g gmshScanner) error {
+	n, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(n) != 1 {
+		return errors.New("bad number of names")
+	}
+	for i := 0; i < n[0]; i++ {
+		str, err := g.line()
+		if err != nil {
+			return err
+		}
+		// The name is quoted and may contain whitespace.
+		strs := strings.Fields(str)
+		start := strings.IndexByte(str, '"')
+		end := strings.LastIndexByte(str, '"')
+		if len(strs) < 3 || start == -1 || end == start {
+			return fmt.Errorf("bad physical name %q", str)
+		}
+		dim, err := strconv.Atoi(strs[0])
+		if err != nil {
+			return err
+		}
+		tag, err := strconv.Atoi(strs[1])
+		if err != nil {
+			return err
+		}
+		m.names[[2]int{dim, tag}] = str[start+1 : end]
+	}
+	return nil
+}
+
+func (m *gmshMesh) readEntities(g gmshScanner) error {
+	counts, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(counts) != 4 {
+		return errors.New("bad entity counts")
+	}
+	for dim, n := range counts {
+		for i := 0; i < n; i++ {
+			v, err := g.floats()
+			if err != nil {
+				return err
+			}
+			// Points have a location in version 4.1 and a bounding box
+			// otherwise.
+			nPhys := 7
+			if dim == 0 && m.version >= 4.1 {
+				nPhys = 4
+			}
+			if len(v) <= nPhys || len(v) <= nPhys+int(v[nPhys]) {
+				return fmt.Errorf("bad entity of dimension %d", dim)
+			}
+			physicals := make([]int, int(v[nPhys]))
+			for j := range physicals {
+				physicals[j] = int(v[nPhys+1+j])
+			}
+			m.entities[[2]int{dim, int(v[0])}] = physicals
+		}
+	}
+	return nil
+}
+
+func (m *gmshMesh) readNodes2(g gmshScanner) error {
+	n, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(n) != 1 {
+		return errors.New("bad number of nodes")
+	}
+	for i := 0; i < n[0]; i++ {
+		v, err := g.floats()
+		if err != nil {
+			return err
+		}
+		if len(v) != 4 {
+			return fmt.Errorf("node %d: wrong number of entries", i)
+		}
+		m.nodes[int(v[0])] = v[1:]
+	}
+	return nil
+}
+
+func (m *gmshMesh) readNodes4(g gmshScanner) error {
+	header, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(header) < 2 {
+		return errors.New("bad header")
+	}
+	for block := 0; block < header[0]; block++ {
+		b, err := g.ints()
+		if err != nil {
+			return err
+		}
+		if len(b) != 4 {
+			return fmt.Errorf("block %d: bad header", block)
+		}
+		if b[2] != 0 {
+			return fmt.Errorf("block %d: parametric nodes not supported", block)
+		}
+		n := b[3]
+		if m.version < 4.1 {
+			// Each line has the tag and the location
+			for i := 0; i < n; i++ {
+				v, err := g.floats()
+				if err != nil {
+					return err
+				}
+				if len(v) != 4 {
+					return fmt.Errorf("block %d: wrong number of entries", block)
+				}
+				m.nodes[int(v[0])] = v[1:]
+			}
+			continue
+		}
+		// All of the tags followed by all of the locations
+		tags := make([]int, n)
+		for i := range tags {
+			v, err := g.ints()
+			if err != nil {
+				return err
+			}
+			if len(v) != 1 {
+				return fmt.Errorf("block %d: bad node tag", block)
+			}
+			tags[i] = v[0]
+		}
+		for _, tag := range tags {
+			v, err := g.floats()
+			if err != nil {
+				return err
+			}
+			if len(v) != 3 {
+				return fmt.Errorf("node %d: wrong number of entries", tag)
+			}
+			m.nodes[tag] = v
+		}
+	}
+	return nil
+}
+
+func (m *gmshMesh) readElements2(g gmshScanner) error {
+	n, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(n) != 1 {
+		return errors.New("bad number of elements")
+	}
+	for i := 0; i < n[0]; i++ {
+		v, err := g.ints()
+		if err != nil {
+			return err
+		}
+		if len(v) < 3 || len(v) < 3+v[2] {
+			return fmt.Errorf("element %d: wrong number of entries", i)
+		}
+		var physicals []int
+		if v[2] > 0 && v[3] != 0 {
+			physicals = []int{v[3]}
+		}
+		if err := m.addElement(v[1], physicals, v[3+v[2]:]); err != nil {
+			return fmt.Errorf("element %d: %v", v[0], err)
+		}
+	}
+	return nil
+}
+
+func (m *gmshMesh) readElements4(g gmshScanner) error {
+	header, err := g.ints()
+	if err != nil {
+		return err
+	}
+	if len(header) < 2 {
+		return errors.New("bad header")
+	}
+	for block := 0; block < header[0]; block++ {
+		b, err := g.ints()
+		if err != nil {
+			return err
+		}
+		if len(b) != 4 {
+			return fmt.Errorf("block %d: bad header", block)
+		}
+		dim, entity := b[0], b[1]
+		if m.version < 4.1 {
+			dim, entity = entity, dim
+		}
+		physicals := m.entities[[2]int{dim, entity}]
+		for i := 0; i < b[3]; i++ {
+			v, err := g.ints()
+			if err != nil {
+				return err
+			}
+			if len(v) < 2 {
+				return fmt.Errorf("block %d: bad element", block)
+			}
+			if err := m.addElement(b[2], physicals, v[1:]); err != nil {
+				return fmt.Errorf("element %d: %v", v[0], err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *gmshMesh) addElement(gmshType int, physicals []int, nodes []int) error {
+	if gmshType == 15 {
+		return nil
+	}
+	t, ok := gmshTypes[gmshType]
+	if !ok {
+		return fmt.Errorf("element type %d not supported", gmshType)
+	}
+	if len(nodes) != t.NumNodes() {
+		return fmt.Errorf("%v with %d nodes", t, len(nodes))
+	}
+	if t == Prism {
+		reordered := make([]int, len(nodes))
+		for i, j := range gmshPrismOrder {
+			reordered[i] = nodes[j]
+		}
+		nodes = reordered
+	}
+	m.elements = append(m.elements, gmshElement{
+		Type:      t,
+		Physicals: physicals,
+		Nodes:     nodes,
+	})
+	return nil
+}
+
+// su2 converts the Gmsh mesh to an SU2 mesh.
+func (m *gmshMesh) su2() (*SU2, error) {
+	dim := 0
+	for _, elem := range m.elements {
+		if d := elem.Type.Dim(); d > dim {
+			dim = d
+		}
+	}
+	if dim < 2 {
+		return nil, errors.New("gmsh: no 2D or 3D elements")
+	}
+
+	// Keep the nodes of the volume elements
+	used := make(map[int]bool)
+	for _, elem := range m.elements {
+		if elem.Type.Dim() == dim {
+			for _, node := range elem.Nodes {
+				used[node] = true
+			}
+		}
+	}
+	tags := make([]int, 0, len(used))
+	for tag := range used {
+		tags = append(tags, tag)
+	}
+	sort.Ints(tags)
+	s := &SU2{Dim: dim}
+	index := make(map[int]PointID, len(tags))
+	for i, tag := range tags {
+		x, ok := m.nodes[tag]
+		if !ok {
+			return nil, fmt.Errorf("gmsh: node %d not found", tag)
+		}
+		index[tag] = PointID(i)
+		s.Points = append(s.Points, &Point{
+			Id:       PointID(i),
+			Location: append([]float64(nil), x[:dim]...),
+		})
+	}
+
+	markers := make(map[int]*Marker)
+	var markerTags []int
+	for _, elem := range m.elements {
+		d := elem.Type.Dim()
+		if d != dim && (d != dim-1 || len(elem.Physicals) == 0) {
+			continue
+		}
+		ids := make([]PointID, len(elem.Nodes))
+		for i, node := range elem.Nodes {
+			id, ok := index[node]
+			if !ok {
+				return nil, fmt.Errorf("gmsh: boundary node %d is not part of the mesh", node)
+			}
+			ids[i] = id
+		}
+		if d == dim {
+			s.Elements = append(s.Elements, &Element{
+				Id:        ElementID(len(s.Elements)),
+				Type:      elem.Type,
+				VertexIds: ids,
+			})
+			continue
+		}
+		for _, physical := range elem.Physicals {
+			marker, ok := markers[physical]
+			if !ok {
+				tag, ok := m.names[[2]int{d, physical}]
+				if !ok {
+					tag = strconv.Itoa(physical)
+				}
+				marker = &Marker{Tag: tag}
+				markers[physical] = marker
+				markerTags = append(markerTags, physical)
+			}
+			marker.Elements = append(marker.Elements, Element{
+				Id:        -1,
+				Type:      elem.Type,
+				VertexIds: append([]PointID(nil), ids...),
+			})
+		}
+	}
+	sort.Ints(markerTags)
+	for _, tag := range markerTags {
+		s.Markers = append(s.Markers, markers[tag])
+	}
+	if err := s.initialize(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
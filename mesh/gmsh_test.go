@@ -0,0 +1,144 @@
+package mesh
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var gmsh2 = `$MeshFormat
+2.2 0 8
+$EndMeshFormat
+$PhysicalNames
+3
+1 1 "wall"
+1	2   "farfield"
+2 3 "fluid"
+$EndPhysicalNames
+$Nodes
+5
+1 0 0 0
+2 1 0 0
+3 1 1 0
+4 0 1 0
+5 5 5 0
+$EndNodes
+$Elements
+7
+1 15 2 0 1 1
+2 1 2 1 1 1 2
+3 1 2 2 2 2 3
+4 1 2 2 3 3 4
+5 1 2 2 4 4 1
+6 2 2 3 1 1 2 3
+7 2 2 3 1 1 3 4
+$EndElements
+`
+
+var gmsh41 = `$MeshFormat
+4.1 0 8
+$EndMeshFormat
+$PhysicalNames
+2
+1 1 "wall"
+1 2 "farfield"
+$EndPhysicalNames
+$Entities
+4 4 1 0
+1 0 0 0 0
+2 1 0 0 0
+3 1 1 0 0
+4 0 1 0 0
+1 0 0 0 1 0 0 1 1 2 1 -2
+2 1 0 0 1 1 0 1 2 2 2 -3
+3 0 1 0 1 1 0 1 2 2 3 -4
+4 0 0 0 0 1 0 1 2 2 4 -1
+1 0 0 0 1 1 0 0 4 1 2 3 4
+$EndEntities
+$Nodes
+1 4 1 4
+2 1 0 4
+1
+2
+3
+4
+0 0 0
+1 0 0
+1 1 0
+0 1 0
+$EndNodes
+$Elements
+5 6 1 6
+1 1 1 1
+1 1 2
+1 2 1 1
+2 2 3
+1 3 1 1
+3 3 4
+1 4 1 1
+4 4 1
+2 1 2 2
+5 1 2 3
+6 1 3 4
+$EndElements
+`
+
+var gmshPrism = `$MeshFormat
+2.2 0 8
+$EndMeshFormat
+$Nodes
+6
+1 0 0 0
+2 1 0 0
+3 0 1 0
+4 0 0 1
+5 1 0 1
+6 0 1 1
+$EndNodes
+$Elements
+1
+1 6 2 0 1 1 2 3 4 5 6
+$EndElements
+`
+
+func TestReadGmsh(t *testing.T) {
+	for _, file := range []string{gmsh2, gmsh41} {
+		s, err := ReadGmsh(strings.NewReader(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Dim != 2 || len(s.Points) != 4 || len(s.Elements) != 2 {
+			t.Fatalf("Size mismatch. Found dimension %d, %d points and %d elements", s.Dim, len(s.Points), len(s.Elements))
+		}
+		for _, elem := range s.Elements {
+			if elem.Type != Triangle || s.Volume(elem) != 0.5 {
+				t.Errorf("bad element %v", elem)
+			}
+		}
+		if len(s.Markers) != 2 {
+			t.Fatalf("Marker mismatch. Expected %v, found %v", 2, len(s.Markers))
+		}
+		for _, test := range []struct {
+			tag   string
+			count int
+		}{{"wall", 1}, {"farfield", 3}} {
+			marker := s.Marker(test.tag)
+			if marker == nil || len(marker.Elements) != test.count {
+				t.Errorf("Marker %s mismatch: %v", test.tag, marker)
+			}
+		}
+	}
+
+	s, err := ReadGmsh(strings.NewReader(gmshPrism))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := s.Volume(s.Elements[0]); math.Abs(v-0.5) > 1e-14 {
+		t.Errorf("Prism volume mismatch. Expected %v, found %v", 0.5, v)
+	}
+
+	bad := strings.Replace(gmsh2, "2.2 0 8", "2.2 1 8", 1)
+	if _, err := ReadGmsh(strings.NewReader(bad)); err == nil {
+		t.Errorf("no error for binary file")
+	}
+}
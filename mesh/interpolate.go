@@ -0,0 +1,85 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+)
+
+// Transfer interpolates point data from one mesh to another. Target points
+// inside a source element get the linear interpolation of the values at the
+// element vertices. Other target points, outside the source mesh, get the
+// inverse distance weighted average of the nearest source points.
+type Transfer struct {
+	// Neighbors is the number of source points averaged outside the source
+	// mesh. Zero means 4 in 2D and 8 in 3D.
+	Neighbors int
+	// Power is the exponent of the inverse distance weights. Zero means 2.
+	Power float64
+}
+
+// Interpolate interpolates the data at the source points, where data[i] holds
+// the values at point i, to the target points. It also returns the target
+// points outside the source mesh, which were found by inverse distance
+// weighting.
+func (t Transfer) Interpolate(source *SU2, data [][]float64, target *SU2) ([][]float64, []PointID, error) {
+	if len(data) != len(source.Points) {
+		return nil, nil, fmt.Errorf("interpolate: %d rows of data for %d points", len(data), len(source.Points))
+	}
+	if source.Dim != target.Dim {
+		return nil, nil, fmt.Errorf("interpolate: %dD source and %dD target", source.Dim, target.Dim)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("interpolate: empty source mesh")
+	}
+	nFields := len(data[0])
+	for i, row := range data {
+		if len(row) != nFields {
+			return nil, nil, fmt.Errorf("interpolate: point %d has %d values, expected %d", i, len(row), nFields)
+		}
+	}
+	k := t.Neighbors
+	if k <= 0 {
+		k = 1 << uint(source.Dim)
+	}
+	power := t.Power
+	if power == 0 {
+		power = 2
+	}
+
+	idx := source.SpatialIndex()
+	out := make([][]float64, len(target.Points))
+	var outside []PointID
+	for p, point := range target.Points {
+		row := make([]float64, nFields)
+		out[p] = row
+		x := point.Location
+		if id, xi, ok := idx.Locate(x); ok {
+			elem := source.Elements[id]
+			for j, w := range elem.Type.Shape(xi) {
+				addScaled(row, data[elem.VertexIds[j]], w)
+			}
+			continue
+		}
+		outside = append(outside, PointID(p))
+		ids, dist := idx.KNearest(x, k)
+		if dist[0] == 0 {
+			copy(row, data[ids[0]])
+			continue
+		}
+		var sum float64
+		for i, id := range ids {
+			w := math.Pow(dist[i], -power)
+			addScaled(row, data[id], w)
+			sum += w
+		}
+		scale(row, 1/sum)
+	}
+	return out, outside, nil
+}
+
+// addScaled adds f times y to x.
+func addScaled(x, y []float64, f float64) {
+	for i, v := range y {
+		x[i] += f * v
+	}
+}
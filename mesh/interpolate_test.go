@@ -0,0 +1,126 @@
+package mesh
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	// Linear fields are interpolated exactly inside the source mesh.
+	linear := func(x []float64) []float64 {
+		f := []float64{1, 0}
+		for i, v := range x {
+			f[0] += float64(i+2) * v
+			f[1] -= v
+		}
+		return f
+	}
+	for _, source := range []*SU2{structuredQuads(5, 5), readString(t, hybrid3D)} {
+		data := make([][]float64, len(source.Points))
+		for i, point := range source.Points {
+			data[i] = linear(point.Location)
+		}
+		target, err := source.Refine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, outside, err := Transfer{}.Interpolate(source, data, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outside) != 0 {
+			t.Errorf("%dD: points %v outside the source", source.Dim, outside)
+		}
+		for i, point := range target.Points {
+			if want := linear(point.Location); !closeTo(out[i], want, 1e-12) {
+				t.Errorf("%dD point %d: value mismatch. Expected %v, found %v", source.Dim, i, want, out[i])
+			}
+		}
+	}
+
+	// The target extends one point past the source on every side.
+	source := structuredQuads(5, 5)
+	target := structuredQuads(7, 7)
+	if err := target.Translate([]float64{-1, -1}); err != nil {
+		t.Fatal(err)
+	}
+	data := make([][]float64, len(source.Points))
+	for i := range data {
+		data[i] = []float64{3}
+	}
+	out, outside, err := Transfer{Neighbors: 3, Power: 1}.Interpolate(source, data, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outside) != 24 {
+		t.Errorf("%d points outside, expected 24", len(outside))
+	}
+	for i, row := range out {
+		if math.Abs(row[0]-3) > 1e-14 {
+			t.Errorf("point %d: value mismatch. Expected 3, found %v", i, row[0])
+		}
+	}
+	if _, _, err := (Transfer{}).Interpolate(source, data[1:], target); err == nil {
+		t.Errorf("no error for missing data")
+	}
+}
+
+var restartFile = `"PointID"	"x"	"y"	"Density"
+0	0.0	0.0	1.0
+1	1.0	0.0	1.5
+2	0.0	1.0	2.0
+3	1.0	1.0	2.5
+EXT_ITER= 100
+AOA= 2.5
+`
+
+func TestRestart(t *testing.T) {
+	r, err := ReadRestart(strings.NewReader(restartFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.Fields, []string{"x", "y", "Density"}) {
+		t.Errorf("field mismatch: %v", r.Fields)
+	}
+	if len(r.Data) != 4 || r.Data[3][2] != 2.5 {
+		t.Errorf("data mismatch: %v", r.Data)
+	}
+	if !reflect.DeepEqual(r.Metadata, []string{"EXT_ITER= 100", "AOA= 2.5"}) {
+		t.Errorf("metadata mismatch: %v", r.Metadata)
+	}
+	var b bytes.Buffer
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := ReadRestart(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("round trip mismatch. Expected %v, found %v", r, r2)
+	}
+
+	source := structuredQuads(2, 2)
+	target, err := source.Refine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := r.Transfer(source, target, Transfer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range moved.Data {
+		x := target.Points[i].Location
+		want := []float64{x[0], x[1], 1 + 0.5*x[0] + x[1]}
+		if !closeTo(row, want, 1e-14) {
+			t.Errorf("point %d: value mismatch. Expected %v, found %v", i, want, row)
+		}
+	}
+
+	if _, err := ReadRestart(strings.NewReader("0\t1\t2\n2\t1\t2\n")); err == nil {
+		t.Errorf("no error for points out of order")
+	}
+}
@@ -0,0 +1,139 @@
+package mesh
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// kdTree is a k-d tree over a set of points. The tree is implicit in the
+// order of idx: the point at the middle of a range splits it along the axis
+// stored for it, with smaller coordinates before it.
+type kdTree struct {
+	x    [][]float64
+	idx  []int
+	axis []int // Split axis of the node at each position of idx
+}
+
+func newKDTree(x [][]float64) *kdTree {
+	t := &kdTree{
+		x:    x,
+		idx:  make([]int, len(x)),
+		axis: make([]int, len(x)),
+	}
+	for i := range t.idx {
+		t.idx[i] = i
+	}
+	t.build(0, len(x))
+	return t
+}
+
+// build splits idx[start:end] along the axis with the largest spread.
+func (t *kdTree) build(start, end int) {
+	if end-start <= 1 {
+		return
+	}
+	dim := len(t.x[t.idx[start]])
+	axis, spread := 0, -1.0
+	for d := 0; d < dim; d++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, i := range t.idx[start:end] {
+			lo = math.Min(lo, t.x[i][d])
+			hi = math.Max(hi, t.x[i][d])
+		}
+		if hi-lo > spread {
+			axis, spread = d, hi-lo
+		}
+	}
+	idx := t.idx[start:end]
+	sort.Slice(idx, func(i, j int) bool { return t.x[idx[i]][axis] < t.x[idx[j]][axis] })
+	mid := (start + end) / 2
+	t.axis[mid] = axis
+	t.build(start, mid)
+	t.build(mid+1, end)
+}
+
+// search visits the points that may be within the bound returned by visit,
+// which is called with each point index and its distance from x. The bound
+// may shrink as points are visited.
+func (t *kdTree) search(x []float64, start, end int, bound float64, visit func(i int, d float64) float64) float64 {
+	if start >= end {
+		return bound
+	}
+	mid := (start + end) / 2
+	i := t.idx[mid]
+	bound = visit(i, distance(x, t.x[i]))
+	diff := x[t.axis[mid]] - t.x[i][t.axis[mid]]
+	near, far := [2]int{start, mid}, [2]int{mid + 1, end}
+	if diff > 0 {
+		near, far = far, near
+	}
+	bound = t.search(x, near[0], near[1], bound, visit)
+	if math.Abs(diff) <= bound {
+		bound = t.search(x, far[0], far[1], bound, visit)
+	}
+	return bound
+}
+
+// neighbor is a point found by a search and its distance.
+type neighbor struct {
+	i int
+	d float64
+}
+
+// neighborHeap is a max-heap of the nearest points found so far.
+type neighborHeap []neighbor
+
+func (h neighborHeap) Len() int            { return len(h) }
+func (h neighborHeap) Less(i, j int) bool  { return h[i].d > h[j].d }
+func (h neighborHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x interface{}) { *h = append(*h, x.(neighbor)) }
+func (h *neighborHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// nearest returns the k points closest to x, in order of increasing distance.
+func (t *kdTree) nearest(x []float64, k int) []neighbor {
+	if k <= 0 {
+		return nil
+	}
+	h := make(neighborHeap, 0, k)
+	t.search(x, 0, len(t.idx), math.Inf(1), func(i int, d float64) float64 {
+		if len(h) < k {
+			heap.Push(&h, neighbor{i, d})
+		} else if d < h[0].d {
+			h[0] = neighbor{i, d}
+			heap.Fix(&h, 0)
+		}
+		if len(h) < k {
+			return math.Inf(1)
+		}
+		return h[0].d
+	})
+	sortNeighbors(h)
+	return h
+}
+
+// radius returns the points within r of x, in order of increasing distance.
+func (t *kdTree) radius(x []float64, r float64) []neighbor {
+	var found []neighbor
+	t.search(x, 0, len(t.idx), r, func(i int, d float64) float64 {
+		if d <= r {
+			found = append(found, neighbor{i, d})
+		}
+		return r
+	})
+	sortNeighbors(found)
+	return found
+}
+
+// sortNeighbors sorts by distance, and then by index so that ties are
+// reproducible.
+func sortNeighbors(n []neighbor) {
+	sort.Slice(n, func(i, j int) bool {
+		return n[i].d < n[j].d || n[i].d == n[j].d && n[i].i < n[j].i
+	})
+}
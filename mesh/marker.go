@@ -0,0 +1,30 @@
+package mesh
+
+import "sort"
+
+// Marker returns the marker with the given tag, or nil if the mesh has no such
+// marker.
+func (s *SU2) Marker(tag string) *Marker {
+	for _, marker := range s.Markers {
+		if marker.Tag == tag {
+			return marker
+		}
+	}
+	return nil
+}
+
+// PointIds returns the ids of the points on the marker in increasing order.
+func (m *Marker) PointIds() []PointID {
+	seen := make(map[PointID]bool)
+	var ids []PointID
+	for _, elem := range m.Elements {
+		for _, id := range elem.VertexIds {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
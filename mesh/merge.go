@@ -0,0 +1,197 @@
+package mesh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Stitch describes how to merge meshes that were built in pieces.
+type Stitch struct {
+	// Interfaces are the tags of the markers of each mesh that touch another
+	// mesh. Interfaces[i] holds the tags for mesh i. Points on interfaces are
+	// merged with coincident interface points of other meshes.
+	Interfaces [][]string
+	// Tol is the distance within which interface points are merged. Zero
+	// means a tolerance relative to the size of the meshes.
+	Tol float64
+	// RenameTags gives markers of later meshes with the same tag as a marker
+	// of an earlier mesh a new tag with the mesh index appended, such as
+	// wall_1. Otherwise markers with the same tag are combined. The elements
+	// left on an interface marker are never combined with another marker;
+	// they are renamed if the tag is taken.
+	RenameTags bool
+}
+
+// MergeReport describes the result of Merge.
+type MergeReport struct {
+	// Merged is the number of points removed by merging them with points of
+	// other meshes.
+	Merged int
+	// Unmatched are the interface points of the merged mesh that didn't match
+	// a point of another mesh.
+	Unmatched []PointID
+	// Renamed maps the new tags of renamed markers to their old tags.
+	Renamed map[string]string
+}
+
+func (r *MergeReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "merged points: %d\n", r.Merged)
+	fmt.Fprintf(&b, "unmatched interface points: %d\n", len(r.Unmatched))
+	tags := make([]string, 0, len(r.Renamed))
+	for tag := range r.Renamed {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "renamed %s to %s\n", r.Renamed[tag], tag)
+	}
+	return b.String()
+}
+
+// Merge combines the meshes into one, numbering the points and elements of
+// each mesh after those of the meshes before it. Coincident interface points
+// are merged, keeping the location of the earlier point, and interface marker
+// elements that become interior faces are removed. Interface markers follow
+// the other markers, and those left with no elements are dropped. Markers that
+// were empty in the input are kept.
+func (st Stitch) Merge(meshes []*SU2) (*SU2, *MergeReport, error) {
+	if len(meshes) == 0 {
+		return nil, nil, errors.New("merge: no meshes")
+	}
+	if st.Interfaces != nil && len(st.Interfaces) != len(meshes) {
+		return nil, nil, fmt.Errorf("merge: interfaces for %d meshes, have %d", len(st.Interfaces), len(meshes))
+	}
+	dim := meshes[0].Dim
+	var x [][]float64
+	for k, s := range meshes {
+		if s.Dim != dim {
+			return nil, nil, fmt.Errorf("merge: mesh %d is %dD, expected %dD", k, s.Dim, dim)
+		}
+		for _, point := range s.Points {
+			x = append(x, point.Location)
+		}
+	}
+	tol := st.Tol
+	if tol <= 0 {
+		tol = defaultTol(x)
+	}
+
+	out := &SU2{Dim: dim, SkipNeighbors: meshes[0].SkipNeighbors}
+	r := &MergeReport{Renamed: make(map[string]string)}
+	h := newPointHash(tol)
+	var hashed []PointID // The merged point of each point in h
+	matched := make(map[PointID]bool)
+	interfaceTags := make([]map[string]bool, len(meshes))
+	ids := make([][]PointID, len(meshes)) // The merged id of each point
+	for k, s := range meshes {
+		interfaceTags[k] = make(map[string]bool)
+		onInterface := make(map[PointID]bool)
+		if st.Interfaces != nil {
+			for _, tag := range st.Interfaces[k] {
+				marker := s.Marker(tag)
+				if marker == nil {
+					return nil, nil, fmt.Errorf("merge: mesh %d has no marker %q", k, tag)
+				}
+				interfaceTags[k][tag] = true
+				for _, id := range marker.PointIds() {
+					onInterface[id] = true
+				}
+			}
+		}
+		ids[k] = make([]PointID, len(s.Points))
+		var added []int
+		for i, point := range s.Points {
+			if onInterface[PointID(i)] {
+				// Only points of earlier meshes are in the hash.
+				if j := h.find(point.Location); j != -1 {
+					ids[k][i] = hashed[j]
+					matched[hashed[j]] = true
+					r.Merged++
+					continue
+				}
+				added = append(added, i)
+			}
+			id := PointID(len(out.Points))
+			ids[k][i] = id
+			out.Points = append(out.Points, &Point{Id: id, Location: append([]float64(nil), point.Location...)})
+		}
+		for _, i := range added {
+			h.add(s.Points[i].Location)
+			hashed = append(hashed, ids[k][i])
+		}
+	}
+	for _, id := range hashed {
+		if !matched[id] {
+			r.Unmatched = append(r.Unmatched, id)
+		}
+	}
+
+	for k, s := range meshes {
+		for _, elem := range s.Elements {
+			out.Elements = append(out.Elements, &Element{
+				Id:        ElementID(len(out.Elements)),
+				Type:      elem.Type,
+				VertexIds: mapIds(elem.VertexIds, ids[k]),
+			})
+		}
+	}
+
+	faces := out.faces()
+	markers := make(map[string]*Marker)
+	// The boundary markers are placed first so that the elements left on
+	// interfaces don't take their tags.
+	for _, interfaces := range []bool{false, true} {
+		for k, s := range meshes {
+			for _, marker := range s.Markers {
+				if interfaceTags[k][marker.Tag] != interfaces {
+					continue
+				}
+				var elems []Element
+				for _, elem := range marker.Elements {
+					v := mapIds(elem.VertexIds, ids[k])
+					if interfaces && len(faces[newFaceKey(v)]) > 1 {
+						continue
+					}
+					elems = append(elems, Element{Id: -1, Type: elem.Type, VertexIds: v})
+				}
+				if len(marker.Elements) > 0 && len(elems) == 0 {
+					// The interface is all interior.
+					continue
+				}
+				tag := marker.Tag
+				if _, ok := markers[tag]; ok && (st.RenameTags || interfaces) {
+					tag = marker.Tag + "_" + strconv.Itoa(k)
+					for n := 1; markers[tag] != nil; n++ {
+						tag = marker.Tag + "_" + strconv.Itoa(k) + "_" + strconv.Itoa(n)
+					}
+					r.Renamed[tag] = marker.Tag
+				}
+				m := markers[tag]
+				if m == nil {
+					m = &Marker{Tag: tag}
+					markers[tag] = m
+					out.Markers = append(out.Markers, m)
+				}
+				m.Elements = append(m.Elements, elems...)
+			}
+		}
+	}
+
+	if err := out.initialize(); err != nil {
+		return nil, nil, err
+	}
+	return out, r, nil
+}
+
+// mapIds returns the ids mapped through newID.
+func mapIds(ids, newID []PointID) []PointID {
+	v := make([]PointID, len(ids))
+	for i, id := range ids {
+		v[i] = newID[id]
+	}
+	return v
+}
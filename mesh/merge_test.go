@@ -0,0 +1,141 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+// channelPair returns two channels meeting at x = 1, where the outlet of the
+// first is the inlet of the second.
+func channelPair(t *testing.T, nyA, nyB int) (a, b *SU2) {
+	a, err := Channel{Length: 1, Height: 1, NX: 5, NY: nyA}.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = Channel{Length: 2, Height: 1, NX: 7, NY: nyB}.Mesh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Translate([]float64{1, 0}); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func TestMerge(t *testing.T) {
+	a, b := channelPair(t, 5, 5)
+	a.Markers = append(a.Markers, &Marker{Tag: "empty"})
+	shared := len(a.Marker("outlet").PointIds())
+	st := Stitch{Interfaces: [][]string{{"outlet"}, {"inlet"}}}
+	s, r, err := st.Merge([]*SU2{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Merged != shared || len(r.Unmatched) != 0 {
+		t.Errorf("report mismatch: %v", r)
+	}
+	if len(s.Points) != len(a.Points)+len(b.Points)-shared {
+		t.Errorf("point count mismatch: %d", len(s.Points))
+	}
+	if len(s.Elements) != len(a.Elements)+len(b.Elements) {
+		t.Errorf("element count mismatch: %d", len(s.Elements))
+	}
+	for i, elem := range s.Elements {
+		if elem.Id != ElementID(i) {
+			t.Errorf("element %d has id %d", i, elem.Id)
+		}
+	}
+	for _, test := range []struct {
+		tag   string
+		count int
+	}{
+		{"inlet", len(a.Marker("inlet").Elements)},
+		{"outlet", len(b.Marker("outlet").Elements)},
+		{"wall", len(a.Marker("wall").Elements) + len(b.Marker("wall").Elements)},
+	} {
+		marker := s.Marker(test.tag)
+		if marker == nil || len(marker.Elements) != test.count {
+			t.Errorf("marker %s mismatch: %v", test.tag, marker)
+		}
+	}
+	if s.Marker("empty") == nil {
+		t.Errorf("empty marker removed")
+	}
+	if len(s.Markers) != 4 {
+		t.Errorf("interface markers not removed: %d markers", len(s.Markers))
+	}
+	// The inlet of the merged mesh is the inlet of the first mesh.
+	for _, id := range s.Marker("inlet").PointIds() {
+		if s.Points[id].Location[0] != 0 {
+			t.Errorf("inlet point %d at %v", id, s.Points[id].Location)
+		}
+	}
+	if issues := s.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in merged mesh: %v", issues)
+	}
+	var volume float64
+	for _, elem := range s.Elements {
+		volume += s.Volume(elem)
+	}
+	if math.Abs(volume-3) > 1e-14 {
+		t.Errorf("volume mismatch. Expected 3, found %v", volume)
+	}
+
+	st.RenameTags = true
+	s, r, err = st.Merge([]*SU2{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Marker("wall") == nil || s.Marker("wall_1") == nil || r.Renamed["wall_1"] != "wall" {
+		t.Errorf("wall not renamed: %v", r.Renamed)
+	}
+	if len(s.Marker("wall_1").Elements) != len(b.Marker("wall").Elements) {
+		t.Errorf("renamed marker mismatch")
+	}
+}
+
+func TestMergeUnmatched(t *testing.T) {
+	// The second channel has twice as many points across, so every other
+	// point of its inlet has no match.
+	a, b := channelPair(t, 5, 9)
+	st := Stitch{Interfaces: [][]string{{"outlet"}, {"inlet"}}}
+	s, r, err := st.Merge([]*SU2{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Merged != 5 || len(r.Unmatched) != 4 {
+		t.Fatalf("report mismatch: %v", r)
+	}
+	for _, id := range r.Unmatched {
+		x := s.Points[id].Location
+		if x[0] != 1 {
+			t.Errorf("unmatched point %d at %v", id, x)
+		}
+	}
+	// The faces don't match, so the interface markers remain, renamed so
+	// they aren't combined with the boundary markers.
+	for _, test := range []struct {
+		tag, renamed string
+		count        int
+	}{
+		{"outlet", "", len(b.Marker("outlet").Elements)},
+		{"outlet_0", "outlet", 4},
+		{"inlet", "", len(a.Marker("inlet").Elements)},
+		{"inlet_1", "inlet", 8},
+	} {
+		m := s.Marker(test.tag)
+		if m == nil || len(m.Elements) != test.count {
+			t.Errorf("marker %s mismatch: %v", test.tag, m)
+		}
+		if r.Renamed[test.tag] != test.renamed {
+			t.Errorf("marker %s renamed from %q", test.tag, r.Renamed[test.tag])
+		}
+	}
+
+	if _, _, err := (Stitch{Interfaces: [][]string{{"outlet"}}}).Merge([]*SU2{a, b}); err == nil {
+		t.Errorf("no error for missing interfaces")
+	}
+	if _, _, err := (Stitch{Interfaces: [][]string{{"outlet"}, {"nope"}}}).Merge([]*SU2{a, b}); err == nil {
+		t.Errorf("no error for missing marker")
+	}
+}
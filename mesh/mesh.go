@@ -9,7 +9,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -18,15 +17,16 @@ type PointID int
 type ElementID int
 type VTKType int
 
-const (
-	Quadrilateral VTKType = 9
-)
-
 type SU2 struct {
 	Elements []*Element
 	Points   []*Point
 	Markers  []*Marker
 	Dim      int
+
+	// SkipNeighbors disables building Point.Neighbors and
+	// Point.OrderedNeighbors, which take a lot of memory on large meshes. Use
+	// Adjacency to get the point graph instead.
+	SkipNeighbors bool
 }
 
 type Marker struct {
@@ -48,9 +48,28 @@ type Point struct {
 	OrderedNeighbors []*Point // The neighbors stored in order of PointID
 }
 
-// ReadFrom reads the SU2 mesh from an io.Reader creating the mesh
+// ReadFrom reads the SU2 mesh from an io.Reader creating the mesh. Meshes with
+// more than one zone must be read with MultiZone.
 func (s *SU2) ReadFrom(r io.Reader) (n int64, err error) {
 	scanner := bufio.NewScanner(r)
+	next, n, err := s.parse(scanner)
+	if err != nil {
+		return n, err
+	}
+	if next != "" {
+		return n, errors.New("mesh has more than one zone, use MultiZone")
+	}
+	err = s.initialize()
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// parse reads the blocks of a single zone from the scanner. It stops at the end
+// of the input or at the IZONE line starting the next zone, which is returned.
+func (s *SU2) parse(scanner *bufio.Scanner) (next string, n int64, err error) {
+	var started bool
 	for scanner.Scan() {
 		str := scanner.Text()
 		str = strings.TrimSpace(str)
@@ -60,42 +79,61 @@ func (s *SU2) ReadFrom(r io.Reader) (n int64, err error) {
 		switch {
 		case strings.HasPrefix(str, "%"):
 			// ignore because comment
+		case strings.HasPrefix(str, "NZONE="):
+			strs := strings.Split(str, "=")
+			if len(strs) != 2 {
+				return "", n, errors.New("more than one equals sign in NZONE line")
+			}
+			nZones, err := strconv.Atoi(strings.TrimSpace(strs[1]))
+			if err != nil {
+				return "", n, errors.New("error parsing NZONE: " + err.Error())
+			}
+			if nZones != 1 {
+				return "", n, fmt.Errorf("mesh has %d zones, use MultiZone", nZones)
+			}
+		case strings.HasPrefix(str, "IZONE="):
+			if started {
+				return str, n, nil
+			}
 		case strings.HasPrefix(str, "NDIME="):
+			started = true
 			// Parse the number of dimensions
 			strs := strings.Split(str, "=")
 			if len(strs) != 2 {
-				return n, errors.New("more than one equals sign in NDIME line")
+				return "", n, errors.New("more than one equals sign in NDIME line")
 			}
 			str = strings.TrimSpace(strs[1])
 			s.Dim, err = strconv.Atoi(str)
 			if err != nil {
-				return n, errors.New("error parsing NDIME: " + err.Error())
+				return "", n, errors.New("error parsing NDIME: " + err.Error())
 			}
 		case strings.HasPrefix(str, "NELEM="):
+			started = true
 			read, err := s.parseElements(scanner, str)
 			n += read
 			if err != nil {
-				return n, err
+				return "", n, err
 			}
 		case strings.HasPrefix(str, "NPOIN="):
+			started = true
 			read, err := s.parsePoints(scanner, str)
 			n += read
 			if err != nil {
-				return n, err
+				return "", n, err
 			}
 		case strings.HasPrefix(str, "NMARK="):
+			started = true
 			read, err := s.parseMarkers(scanner, str)
 			n += read
 			if err != nil {
-				return n, err
+				return "", n, err
 			}
 		}
 	}
-	err = s.initialize()
-	if err != nil {
-		return n, err
+	if err := scanner.Err(); err != nil {
+		return "", n, err
 	}
-	return n, nil
+	return "", n, nil
 }
 
 func (s *SU2) parseElements(scanner *bufio.Scanner, str string) (n int64, err error) {
@@ -113,7 +151,7 @@ func (s *SU2) parseElements(scanner *bufio.Scanner, str string) (n int64, err er
 	for i := 0; i < nelem; i++ {
 		scanner.Scan()
 		if scanner.Err() != nil {
-			return n, fmt.Errorf("error scanning element %d: " + scanner.Err().Error())
+			return n, fmt.Errorf("error scanning element %d: %v", i, scanner.Err())
 		}
 		elem := &Element{}
 		str := scanner.Text()
@@ -169,7 +207,7 @@ func (s *SU2) parsePoints(scanner *bufio.Scanner, str string) (n int64, err erro
 	for i := 0; i < npoints; i++ {
 		scanner.Scan()
 		if scanner.Err() != nil {
-			return n, fmt.Errorf("error scanning point %d: " + scanner.Err().Error())
+			return n, fmt.Errorf("error scanning point %d: %v", i, scanner.Err())
 		}
 		str := scanner.Text()
 		str = strings.TrimSpace(str)
@@ -213,7 +251,7 @@ func (s *SU2) parseMarkers(scanner *bufio.Scanner, str string) (n int64, err err
 	for i := 0; i < nMarkers; i++ {
 		scanner.Scan()
 		if scanner.Err() != nil {
-			return n, fmt.Errorf("error scanning marker %d: " + scanner.Err().Error())
+			return n, fmt.Errorf("error scanning marker %d: %v", i, scanner.Err())
 		}
 		str := scanner.Text()
 		if !strings.HasPrefix(str, "MARKER_TAG") {
@@ -224,10 +262,10 @@ func (s *SU2) parseMarkers(scanner *bufio.Scanner, str string) (n int64, err err
 			return n, fmt.Errorf("marker %d: MARKER_TAG doesn't have exactly one equals sign ", i)
 		}
 		marker := &Marker{}
-		marker.Tag = strs[1]
+		marker.Tag = strings.TrimSpace(strs[1])
 		scanner.Scan()
 		if scanner.Err() != nil {
-			return n, fmt.Errorf("error scanning MARKER_ELEMS %d: " + scanner.Err().Error())
+			return n, fmt.Errorf("error scanning MARKER_ELEMS %d: %v", i, scanner.Err())
 		}
 		str = scanner.Text()
 		if !strings.HasPrefix(str, "MARKER_ELEMS") {
@@ -241,7 +279,7 @@ func (s *SU2) parseMarkers(scanner *bufio.Scanner, str string) (n int64, err err
 		str = strings.TrimSpace(strs[1])
 		nMarkerElems, err := strconv.Atoi(str)
 		if err != nil {
-			return n, fmt.Errorf("marker %d:  MARKER_ELEMS parsing error: ", err.Error())
+			return n, fmt.Errorf("marker %d: MARKER_ELEMS parsing error: %v", i, err)
 		}
 		marker.Elements = make([]Element, nMarkerElems)
 		for j := 0; j < nMarkerElems; j++ {
@@ -273,46 +311,36 @@ func (s *SU2) parseMarkers(scanner *bufio.Scanner, str string) (n int64, err err
 }
 
 func (s *SU2) initialize() error {
-	// Create all of the neighbor maps
-	for _, point := range s.Points {
-		point.Neighbors = make(map[PointID]*Point)
-	}
-	// Add all of the neighboring points
 	for _, elem := range s.Elements {
-		switch elem.Type {
-		case Quadrilateral:
-			l := len(elem.VertexIds)
-			if l != 4 {
-				return fmt.Errorf("Incorrect number of nodes in element %v", elem.Id)
-			}
-			// The neighbors are the adjacent nodes in the list
-			for j, id := range elem.VertexIds {
-				neighbor := elem.VertexIds[(j+1)%l]
-				s.Points[id].Neighbors[neighbor] = s.Points[neighbor]
-				neighbor = elem.VertexIds[(j+l-1)%l]
-				s.Points[id].Neighbors[neighbor] = s.Points[neighbor]
-			}
-		default:
+		if !elem.Type.Supported() {
 			return fmt.Errorf("element type %d not implemented", elem.Type)
 		}
-	}
-	for _, point := range s.Points {
-		nNeighbors := len(point.Neighbors)
-		neighborIds := make([]int, nNeighbors)
-		var count int
-		for id := range point.Neighbors {
-			neighborIds[count] = int(id)
-			count++
+		if len(elem.VertexIds) != elem.Type.NumNodes() {
+			return fmt.Errorf("Incorrect number of nodes in element %v", elem.Id)
 		}
-		sort.Ints(neighborIds)
-		point.OrderedNeighbors = make([]*Point, nNeighbors)
-		for i, id := range neighborIds {
-			var ok bool
-			point.OrderedNeighbors[i], ok = point.Neighbors[PointID(id)]
-			if !ok {
-				panic("bad neighbor")
+		for _, id := range elem.VertexIds {
+			if id < 0 || int(id) >= len(s.Points) {
+				return fmt.Errorf("element %v: point %v out of range", elem.Id, id)
 			}
 		}
 	}
+	if s.SkipNeighbors {
+		for _, point := range s.Points {
+			point.Neighbors = nil
+			point.OrderedNeighbors = nil
+		}
+		return nil
+	}
+	// The neighbors are the points sharing an edge of an element
+	adj := s.Adjacency()
+	for i, point := range s.Points {
+		neighbors := adj.Neighbors(PointID(i))
+		point.Neighbors = make(map[PointID]*Point, len(neighbors))
+		point.OrderedNeighbors = make([]*Point, len(neighbors))
+		for j, id := range neighbors {
+			point.Neighbors[id] = s.Points[id]
+			point.OrderedNeighbors[j] = s.Points[id]
+		}
+	}
 	return nil
 }
@@ -1,7 +1,10 @@
 package mesh
 
 import (
+	"bytes"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -9,14 +12,168 @@ func TestReadFrom(t *testing.T) {
 	filename := "mesh_flatplate_turb_137x97.su2"
 	f, err := os.Open(filename)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 	s := &SU2{}
 	_, err = s.ReadFrom(f)
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 	if s.Dim != 2 {
 		t.Errorf("Dimension mismatch. Expected %v, found %v", 2, s.Dim)
 	}
 }
+
+// hybrid2D is a unit square split into a quadrilateral and two triangles.
+var hybrid2D = `NDIME= 2
+NELEM= 3
+9	0	1	4	3	0
+5	1	2	4	1
+5	2	5	4	2
+NPOIN= 6
+0	0	0
+0.5	0	1
+1	0	2
+0	1	3
+0.5	1	4
+1	1	5
+NMARK= 2
+MARKER_TAG= lower
+MARKER_ELEMS= 2
+3	0	1
+3	1	2
+MARKER_TAG= upper
+MARKER_ELEMS= 2
+3	5	4
+3	4	3
+`
+
+// hybrid3D is a hexahedron with a pyramid on top, a tetrahedron beside the
+// pyramid and a prism beside the hexahedron.
+var hybrid3D = `NDIME= 3
+NELEM= 4
+12	0	1	2	3	4	5	6	7	0
+14	4	5	6	7	8	1
+10	5	9	6	8	2
+13	1	2	10	5	6	11	3
+NPOIN= 12
+0	0	0	0
+1	0	0	1
+1	1	0	2
+0	1	0	3
+0	0	1	4
+1	0	1	5
+1	1	1	6
+0	1	1	7
+0.5	0.5	1.5	8
+1.5	0.5	1.5	9
+2	0	0	10
+2	0	1	11
+NMARK= 0
+`
+
+func TestReadFromHybrid(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		file      string
+		neighbors map[PointID][]PointID
+	}{
+		{
+			name: "2D",
+			file: hybrid2D,
+			neighbors: map[PointID][]PointID{
+				0: {1, 3},
+				1: {0, 2, 4},
+				4: {1, 2, 3, 5},
+			},
+		},
+		{
+			name: "3D",
+			file: hybrid3D,
+			neighbors: map[PointID][]PointID{
+				0:  {1, 3, 4},
+				5:  {1, 4, 6, 8, 9, 11},
+				6:  {2, 5, 7, 8, 9, 11},
+				8:  {4, 5, 6, 7, 9},
+				10: {1, 2, 11},
+			},
+		},
+	} {
+		s := &SU2{}
+		_, err := s.ReadFrom(strings.NewReader(test.file))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		for id, want := range test.neighbors {
+			got := s.Points[id].OrderedNeighbors
+			if len(got) != len(want) {
+				t.Errorf("%s: point %d: expected %d neighbors, found %d", test.name, id, len(want), len(got))
+				continue
+			}
+			for i, p := range got {
+				if p.Id != want[i] {
+					t.Errorf("%s: point %d: neighbor mismatch. Expected %v, found %v", test.name, id, want[i], p.Id)
+				}
+			}
+		}
+	}
+}
+
+func TestReadFromBadElement(t *testing.T) {
+	file := strings.Replace(hybrid2D, "5	1	2	4	1", "5	1	2	1", 1)
+	s := &SU2{}
+	_, err := s.ReadFrom(strings.NewReader(file))
+	if err == nil {
+		t.Errorf("no error for triangle with two nodes")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	filename := "mesh_flatplate_turb_137x97.su2"
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := &SU2{}
+	_, err = s.ReadFrom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := s.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("byte count mismatch. Expected %v, found %v", buf.Len(), n)
+	}
+	s2 := &SU2{}
+	_, err = s2.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("error reading written mesh: %v", err)
+	}
+	if !sameMesh(s, s2) {
+		t.Errorf("mesh changed after writing and reading")
+	}
+}
+
+// sameMesh returns true if the two meshes have the same dimension, elements,
+// point locations and markers.
+func sameMesh(a, b *SU2) bool {
+	if a.Dim != b.Dim || len(a.Elements) != len(b.Elements) || len(a.Points) != len(b.Points) || len(a.Markers) != len(b.Markers) {
+		return false
+	}
+	for i := range a.Elements {
+		if !reflect.DeepEqual(a.Elements[i], b.Elements[i]) {
+			return false
+		}
+	}
+	for i := range a.Points {
+		if a.Points[i].Id != b.Points[i].Id || !reflect.DeepEqual(a.Points[i].Location, b.Points[i].Location) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(a.Markers, b.Markers)
+}
@@ -0,0 +1,204 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MultiZone is a mesh made of several zones, such as the fluid and solid
+// domains of a fluid-structure case or the rotor and stator of a turbomachine.
+// Each zone is a separate SU2 mesh with its own point and element numbering.
+type MultiZone struct {
+	Zones []*SU2
+
+	// SkipNeighbors is passed to each zone when reading. See SU2.
+	SkipNeighbors bool
+}
+
+// ReadFrom reads a multi-zone mesh from an io.Reader. The mesh must start with
+// an NZONE line followed by an IZONE line for each zone.
+func (m *MultiZone) ReadFrom(r io.Reader) (n int64, err error) {
+	scanner := bufio.NewScanner(r)
+	nZones := -1
+	var next string
+	for scanner.Scan() {
+		str := strings.TrimSpace(scanner.Text())
+		if len(str) == 0 || strings.HasPrefix(str, "%") {
+			continue
+		}
+		if nZones == -1 {
+			if !strings.HasPrefix(str, "NZONE=") {
+				return n, errors.New("multi-zone mesh must start with NZONE")
+			}
+			strs := strings.Split(str, "=")
+			if len(strs) != 2 {
+				return n, errors.New("more than one equals sign in NZONE line")
+			}
+			nZones, err = strconv.Atoi(strings.TrimSpace(strs[1]))
+			if err != nil {
+				return n, errors.New("error parsing NZONE: " + err.Error())
+			}
+			if nZones < 1 {
+				return n, fmt.Errorf("bad number of zones: %d", nZones)
+			}
+			continue
+		}
+		if !strings.HasPrefix(str, "IZONE=") {
+			return n, errors.New("no IZONE after NZONE")
+		}
+		next = str
+		break
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	if next == "" {
+		return n, errors.New("no IZONE found")
+	}
+
+	zones := make([]*SU2, nZones)
+	for i := range zones {
+		if next == "" {
+			return n, fmt.Errorf("expected %d zones, found %d", nZones, i)
+		}
+		strs := strings.Split(next, "=")
+		if len(strs) != 2 {
+			return n, fmt.Errorf("zone %d: IZONE doesn't have exactly one equals sign", i)
+		}
+		izone, err := strconv.Atoi(strings.TrimSpace(strs[1]))
+		if err != nil {
+			return n, fmt.Errorf("zone %d: error parsing IZONE: %v", i, err)
+		}
+		if izone != i+1 {
+			return n, fmt.Errorf("zone %d: bad IZONE %d", i, izone)
+		}
+		zone := &SU2{SkipNeighbors: m.SkipNeighbors}
+		var read int64
+		next, read, err = zone.parse(scanner)
+		n += read
+		if err != nil {
+			return n, fmt.Errorf("zone %d: %v", i, err)
+		}
+		err = zone.initialize()
+		if err != nil {
+			return n, fmt.Errorf("zone %d: %v", i, err)
+		}
+		zones[i] = zone
+	}
+	if next != "" {
+		return n, fmt.Errorf("more than %d zones in mesh", nZones)
+	}
+	m.Zones = zones
+	return n, nil
+}
+
+// WriteTo writes the multi-zone mesh to an io.Writer in the format accepted by
+// ReadFrom. Zones are numbered from 1 as in SU2.
+func (m *MultiZone) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &countWriter{w: w}
+	_, err = fmt.Fprintf(cw, "NZONE= %d\n", len(m.Zones))
+	if err != nil {
+		return cw.n, err
+	}
+	for i, zone := range m.Zones {
+		_, err = fmt.Fprintf(cw, "IZONE= %d\n", i+1)
+		if err != nil {
+			return cw.n, err
+		}
+		_, err = zone.WriteTo(cw)
+		if err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// Marker returns the marker with the given tag in a zone, or nil if there is
+// no such zone or the zone has no such marker.
+func (m *MultiZone) Marker(zone int, tag string) *Marker {
+	if zone < 0 || zone >= len(m.Zones) {
+		return nil
+	}
+	return m.Zones[zone].Marker(tag)
+}
+
+// SharedMarkers returns the marker tags that appear in more than one zone,
+// mapped to the zones that contain them.
+func (m *MultiZone) SharedMarkers() map[string][]int {
+	zones := make(map[string][]int)
+	for i, zone := range m.Zones {
+		for _, marker := range zone.Markers {
+			zones[marker.Tag] = append(zones[marker.Tag], i)
+		}
+	}
+	for tag, z := range zones {
+		if len(z) < 2 {
+			delete(zones, tag)
+		}
+	}
+	return zones
+}
+
+// InterfacePair is a pair of coincident points on the interface between two
+// zones. A is the point in the first zone and B the point in the second.
+type InterfacePair struct {
+	A, B PointID
+}
+
+// MatchInterface matches the points on marker tagA of zone a with the points
+// on marker tagB of zone b. Each point of tagA is paired with the closest point
+// of tagB within a distance tol. Points of either marker without a match are
+// returned in unmatchedA and unmatchedB.
+func (m *MultiZone) MatchInterface(a int, tagA string, b int, tagB string, tol float64) (pairs []InterfacePair, unmatchedA, unmatchedB []PointID, err error) {
+	markerA := m.Marker(a, tagA)
+	if markerA == nil {
+		return nil, nil, nil, fmt.Errorf("zone %d has no marker %s", a, tagA)
+	}
+	markerB := m.Marker(b, tagB)
+	if markerB == nil {
+		return nil, nil, nil, fmt.Errorf("zone %d has no marker %s", b, tagB)
+	}
+	pointsA := m.Zones[a].Points
+	pointsB := m.Zones[b].Points
+
+	// Sort the points of B by their first coordinate so only a narrow band of
+	// them needs to be checked for each point of A.
+	idsB := markerB.PointIds()
+	sort.Slice(idsB, func(i, j int) bool {
+		return pointsB[idsB[i]].Location[0] < pointsB[idsB[j]].Location[0]
+	})
+	matchedB := make(map[PointID]bool)
+	for _, idA := range markerA.PointIds() {
+		x := pointsA[idA].Location
+		start := sort.Search(len(idsB), func(i int) bool {
+			return pointsB[idsB[i]].Location[0] >= x[0]-tol
+		})
+		best := -1
+		bestDist := math.Inf(1)
+		for i := start; i < len(idsB) && pointsB[idsB[i]].Location[0] <= x[0]+tol; i++ {
+			d := distance(x, pointsB[idsB[i]].Location)
+			if d <= tol && d < bestDist {
+				best = i
+				bestDist = d
+			}
+		}
+		if best == -1 {
+			unmatchedA = append(unmatchedA, idA)
+			continue
+		}
+		pairs = append(pairs, InterfacePair{A: idA, B: idsB[best]})
+		matchedB[idsB[best]] = true
+	}
+	for _, idB := range markerB.PointIds() {
+		if !matchedB[idB] {
+			unmatchedB = append(unmatchedB, idB)
+		}
+	}
+	return pairs, unmatchedA, unmatchedB, nil
+}
@@ -0,0 +1,82 @@
+package mesh
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var upperZone = `NDIME= 2
+NELEM= 1
+9	0	1	2	3	0
+NPOIN= 4
+0	1	0
+1	1	1
+1	2	2
+0	2	3
+NMARK= 2
+MARKER_TAG= interface
+MARKER_ELEMS= 1
+3	0	1
+MARKER_TAG= upper
+MARKER_ELEMS= 1
+3	2	3
+`
+
+func TestMultiZone(t *testing.T) {
+	file := "NZONE= 2\nIZONE= 1\n" + strings.Replace(hybrid2D, "upper", "interface", 1) + "IZONE= 2\n" + upperZone
+	m := &MultiZone{}
+	_, err := m.ReadFrom(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Zones) != 2 {
+		t.Fatalf("Zone mismatch. Expected %v, found %v", 2, len(m.Zones))
+	}
+	if len(m.Zones[0].Points) != 6 || len(m.Zones[1].Points) != 4 {
+		t.Errorf("zones overwritten while reading")
+	}
+	shared := m.SharedMarkers()
+	if len(shared) != 1 || len(shared["interface"]) != 2 {
+		t.Errorf("Shared marker mismatch. Found %v", shared)
+	}
+	pairs, unmatchedA, unmatchedB, err := m.MatchInterface(0, "interface", 1, "interface", 1e-10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Marker(2, "interface") != nil || m.Marker(-1, "interface") != nil {
+		t.Errorf("marker found in a missing zone")
+	}
+	if _, _, _, err := m.MatchInterface(0, "interface", 2, "interface", 1e-10); err == nil {
+		t.Errorf("no error for a missing zone")
+	}
+	wantPairs := []InterfacePair{{A: 3, B: 0}, {A: 5, B: 1}}
+	if len(pairs) != len(wantPairs) || pairs[0] != wantPairs[0] || pairs[1] != wantPairs[1] {
+		t.Errorf("Pair mismatch. Expected %v, found %v", wantPairs, pairs)
+	}
+	if len(unmatchedA) != 1 || unmatchedA[0] != 4 || len(unmatchedB) != 0 {
+		t.Errorf("Unmatched mismatch. Found %v and %v", unmatchedA, unmatchedB)
+	}
+
+	var buf bytes.Buffer
+	_, err = m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2 := &MultiZone{}
+	_, err = m2.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("error reading written mesh: %v", err)
+	}
+	for i := range m.Zones {
+		if !sameMesh(m.Zones[i], m2.Zones[i]) {
+			t.Errorf("zone %d changed after writing and reading", i)
+		}
+	}
+
+	s := &SU2{}
+	_, err = s.ReadFrom(strings.NewReader(file))
+	if err == nil {
+		t.Errorf("no error reading multi-zone mesh as a single zone")
+	}
+}
@@ -0,0 +1,548 @@
+package mesh
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// PartitionMethod is the algorithm used to partition a mesh.
+type PartitionMethod int
+
+const (
+	// RCB recursively splits the points in half across the longest side of
+	// their bounding box.
+	RCB PartitionMethod = iota
+	// Multilevel recursively bisects the point graph by coarsening it with
+	// heavy edge matching, bisecting the coarsest graph by growing a region
+	// and refining the cut at each finer level.
+	Multilevel
+)
+
+// Partition is an assignment of the points and elements of a mesh to parts.
+// Each element belongs to the part holding most of its points, or the lowest
+// numbered of those parts on a tie. The other points of the elements touching a
+// part are its ghost points.
+type Partition struct {
+	NumParts int
+	Points   []int // Part of each point
+	Elements []int // Part of each element
+
+	PointCounts   []int // Number of points in each part
+	ElementCounts []int // Number of elements in each part
+	GhostCounts   []int // Number of ghost points of each part
+
+	// EdgeCut is the number of edges between points in different parts.
+	EdgeCut int
+	// Imbalance is the size of the largest part divided by the mean size.
+	Imbalance float64
+}
+
+// Partition splits the mesh into n parts of nearly equal numbers of points.
+// The result is the same every time for the same mesh.
+func (s *SU2) Partition(n int, method PartitionMethod) (*Partition, error) {
+	if n < 1 || n > len(s.Points) {
+		return nil, fmt.Errorf("partition: can't split %d points into %d parts", len(s.Points), n)
+	}
+	adj := s.Adjacency()
+	part := make([]int, len(s.Points))
+	all := make([]int, len(s.Points))
+	for i := range all {
+		all[i] = i
+	}
+	switch method {
+	case RCB:
+		s.rcb(all, 0, n, part)
+	case Multilevel:
+		g := newPointGraph(adj)
+		g.partition(all, 0, n, part, rand.New(rand.NewSource(1)))
+	default:
+		return nil, errors.New("partition: unknown method")
+	}
+	return s.newPartition(n, part, adj), nil
+}
+
+// rcb assigns the points to parts first to first+n-1 by recursive coordinate
+// bisection.
+func (s *SU2) rcb(points []int, first, n int, part []int) {
+	if n == 1 {
+		for _, p := range points {
+			part[p] = first
+		}
+		return
+	}
+	lo := append([]float64(nil), s.Points[points[0]].Location...)
+	hi := append([]float64(nil), lo...)
+	for _, p := range points {
+		for d, v := range s.Points[p].Location {
+			if v < lo[d] {
+				lo[d] = v
+			}
+			if v > hi[d] {
+				hi[d] = v
+			}
+		}
+	}
+	dir := 0
+	for d := range lo {
+		if hi[d]-lo[d] > hi[dir]-lo[dir] {
+			dir = d
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return s.Points[points[i]].Location[dir] < s.Points[points[j]].Location[dir]
+	})
+	n0 := n / 2
+	split := len(points) * n0 / n
+	s.rcb(points[:split], first, n0, part)
+	s.rcb(points[split:], first+n0, n-n0, part)
+}
+
+// newPartition finds the elements, ghost points and statistics of the point
+// partition.
+func (s *SU2) newPartition(n int, part []int, adj *Adjacency) *Partition {
+	p := &Partition{
+		NumParts:      n,
+		Points:        part,
+		Elements:      make([]int, len(s.Elements)),
+		PointCounts:   make([]int, n),
+		ElementCounts: make([]int, n),
+		GhostCounts:   make([]int, n),
+	}
+	for _, q := range part {
+		p.PointCounts[q]++
+	}
+	// The parts each point is a ghost of
+	ghost := make([][]int, len(s.Points))
+	count := make([]int, n)
+	var parts []int
+	for i, elem := range s.Elements {
+		parts = parts[:0]
+		for _, id := range elem.VertexIds {
+			q := part[id]
+			if count[q] == 0 {
+				parts = append(parts, q)
+			}
+			count[q]++
+		}
+		best := parts[0]
+		for _, q := range parts {
+			if count[q] > count[best] || count[q] == count[best] && q < best {
+				best = q
+			}
+		}
+		p.Elements[i] = best
+		p.ElementCounts[best]++
+		for _, q := range parts {
+			count[q] = 0
+			for _, id := range elem.VertexIds {
+				if part[id] != q && !containsInt(ghost[id], q) {
+					ghost[id] = append(ghost[id], q)
+				}
+			}
+		}
+	}
+	for _, qs := range ghost {
+		for _, q := range qs {
+			p.GhostCounts[q]++
+		}
+	}
+	for _, edge := range adj.Edges {
+		if part[edge[0]] != part[edge[1]] {
+			p.EdgeCut++
+		}
+	}
+	for _, c := range p.PointCounts {
+		imbalance := float64(c) * float64(n) / float64(len(part))
+		if imbalance > p.Imbalance {
+			p.Imbalance = imbalance
+		}
+	}
+	return p
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// VTKData returns the part of each point and element as data arrays named
+// Partition, for writing with WriteVTU or WriteVTK.
+func (p *Partition) VTKData() *VTKData {
+	field := func(parts []int) VTKArray {
+		a := VTKArray{Name: "Partition", Components: 1, Data: make([]float64, len(parts))}
+		for i, q := range parts {
+			a.Data[i] = float64(q)
+		}
+		return a
+	}
+	return &VTKData{
+		PointData: []VTKArray{field(p.Points)},
+		CellData:  []VTKArray{field(p.Elements)},
+	}
+}
+
+func (p *Partition) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "parts: %d\n", p.NumParts)
+	fmt.Fprintf(&b, "edge cut: %d\n", p.EdgeCut)
+	fmt.Fprintf(&b, "imbalance: %g\n", p.Imbalance)
+	b.WriteString("part\tpoints\telements\tghosts\n")
+	for q := 0; q < p.NumParts; q++ {
+		fmt.Fprintf(&b, "%d\t%d\t%d\t%d\n", q, p.PointCounts[q], p.ElementCounts[q], p.GhostCounts[q])
+	}
+	return b.String()
+}
+
+// graph is a weighted graph in compressed sparse row form.
+type graph struct {
+	offsets    []int
+	adj        []int
+	adjWeight  []int
+	weight     []int
+	maxWeight  int
+	sumWeights int
+}
+
+const (
+	// Graphs with fewer vertices are bisected directly.
+	coarsestGraph = 64
+	// The allowed relative imbalance of a bisection
+	bisectTolerance = 0.03
+)
+
+func newPointGraph(a *Adjacency) *graph {
+	g := &graph{
+		offsets:   a.Offsets,
+		adj:       make([]int, len(a.Indices)),
+		adjWeight: make([]int, len(a.Indices)),
+		weight:    make([]int, a.NumPoints()),
+	}
+	for i, id := range a.Indices {
+		g.adj[i] = int(id)
+		g.adjWeight[i] = 1
+	}
+	for i := range g.weight {
+		g.weight[i] = 1
+	}
+	g.setTotals()
+	return g
+}
+
+func (g *graph) n() int {
+	return len(g.weight)
+}
+
+func (g *graph) setTotals() {
+	g.maxWeight, g.sumWeights = 0, 0
+	for _, w := range g.weight {
+		g.sumWeights += w
+		if w > g.maxWeight {
+			g.maxWeight = w
+		}
+	}
+}
+
+// partition assigns the vertices, whose ids in the full graph are given, to
+// parts first to first+n-1 by recursive bisection.
+func (g *graph) partition(vertices []int, first, n int, part []int, rng *rand.Rand) {
+	if n == 1 {
+		for _, v := range vertices {
+			part[v] = first
+		}
+		return
+	}
+	n0 := n / 2
+	side := g.bisect(float64(n0)/float64(n), [2]int{n0, n - n0}, rng)
+	var local [2][]int
+	for v, sd := range side {
+		local[sd] = append(local[sd], v)
+	}
+	for sd, vs := range local {
+		global := make([]int, len(vs))
+		for i, v := range vs {
+			global[i] = vertices[v]
+		}
+		if sd == 0 {
+			g.subgraph(vs).partition(global, first, n0, part, rng)
+		} else {
+			g.subgraph(vs).partition(global, first+n0, n-n0, part, rng)
+		}
+	}
+}
+
+// subgraph returns the graph induced by the vertices.
+func (g *graph) subgraph(vertices []int) *graph {
+	index := make(map[int]int, len(vertices))
+	for i, v := range vertices {
+		index[v] = i
+	}
+	sub := &graph{
+		offsets: make([]int, 1, len(vertices)+1),
+		weight:  make([]int, len(vertices)),
+	}
+	for i, v := range vertices {
+		sub.weight[i] = g.weight[v]
+		for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+			if u, ok := index[g.adj[k]]; ok {
+				sub.adj = append(sub.adj, u)
+				sub.adjWeight = append(sub.adjWeight, g.adjWeight[k])
+			}
+		}
+		sub.offsets = append(sub.offsets, len(sub.adj))
+	}
+	sub.setTotals()
+	return sub
+}
+
+// bisect splits the graph in two, with the fraction frac of the total vertex
+// weight on side 0, by multilevel bisection. Each side keeps at least the
+// weight in need, so that it can be split further. It returns the side of each
+// vertex.
+func (g *graph) bisect(frac float64, need [2]int, rng *rand.Rand) []int {
+	if g.n() > coarsestGraph {
+		coarse, cmap := g.coarsen(rng)
+		if coarse.n() < g.n()*9/10 {
+			cside := coarse.bisect(frac, need, rng)
+			side := make([]int, g.n())
+			for v := range side {
+				side[v] = cside[cmap[v]]
+			}
+			g.refine(side, frac, need)
+			return side
+		}
+	}
+	// Grow regions from a few seeds and keep the smallest cut.
+	var best []int
+	bestCut := -1
+	for try := 0; try < 4; try++ {
+		side := g.grow(rng.Intn(g.n()), frac)
+		g.refine(side, frac, need)
+		if cut := g.cut(side); bestCut == -1 || cut < bestCut {
+			best, bestCut = side, cut
+		}
+	}
+	return best
+}
+
+// coarsen matches every vertex with its unmatched neighbor joined by the
+// heaviest edge, and returns the graph of the matched pairs and the coarse
+// vertex of each vertex.
+func (g *graph) coarsen(rng *rand.Rand) (*graph, []int) {
+	n := g.n()
+	match := make([]int, n)
+	for v := range match {
+		match[v] = -1
+	}
+	for _, v := range rng.Perm(n) {
+		if match[v] != -1 {
+			continue
+		}
+		best, bestWeight := v, 0
+		for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+			u := g.adj[k]
+			if match[u] == -1 && u != v && g.adjWeight[k] > bestWeight {
+				best, bestWeight = u, g.adjWeight[k]
+			}
+		}
+		match[v], match[best] = best, v
+	}
+	cmap := make([]int, n)
+	for v := range cmap {
+		cmap[v] = -1
+	}
+	nc := 0
+	for v := range cmap {
+		if cmap[v] == -1 {
+			cmap[v], cmap[match[v]] = nc, nc
+			nc++
+		}
+	}
+
+	coarse := &graph{
+		offsets: make([]int, 1, nc+1),
+		weight:  make([]int, nc),
+	}
+	// pos holds the index in coarse.adj of each neighbor of the current
+	// coarse vertex, to merge parallel edges.
+	pos := make([]int, nc)
+	for i := range pos {
+		pos[i] = -1
+	}
+	c := 0
+	for v := range cmap {
+		if cmap[v] != c {
+			continue
+		}
+		start := len(coarse.adj)
+		for _, w := range []int{v, match[v]} {
+			coarse.weight[c] += g.weight[w]
+			for k := g.offsets[w]; k < g.offsets[w+1]; k++ {
+				u := cmap[g.adj[k]]
+				if u == c {
+					continue
+				}
+				if pos[u] == -1 {
+					pos[u] = len(coarse.adj)
+					coarse.adj = append(coarse.adj, u)
+					coarse.adjWeight = append(coarse.adjWeight, 0)
+				}
+				coarse.adjWeight[pos[u]] += g.adjWeight[k]
+			}
+			if match[v] == v {
+				break
+			}
+		}
+		for _, u := range coarse.adj[start:] {
+			pos[u] = -1
+		}
+		coarse.offsets = append(coarse.offsets, len(coarse.adj))
+		c++
+	}
+	coarse.setTotals()
+	return coarse, cmap
+}
+
+// grow returns a bisection made by growing side 0 from the seed, adding the
+// frontier vertex with the largest reduction of the cut until it holds the
+// fraction frac of the weight.
+func (g *graph) grow(seed int, frac float64) []int {
+	n := g.n()
+	side := make([]int, n)
+	for v := range side {
+		side[v] = 1
+	}
+	// gain is the edge weight to side 0 minus the edge weight to side 1 of
+	// the vertices on side 1.
+	gain := make([]int, n)
+	for v := range gain {
+		for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+			gain[v] -= g.adjWeight[k]
+		}
+	}
+	// The frontier holds an entry for every change of gain, and the outdated
+	// entries are skipped.
+	frontier := &gainHeap{{v: seed, gain: gain[seed]}}
+	target := frac * float64(g.sumWeights)
+	weight := 0
+	scan := 0
+	for float64(weight) < target {
+		v := -1
+		for frontier.Len() > 0 {
+			top := heap.Pop(frontier).(gainEntry)
+			if side[top.v] == 1 && gain[top.v] == top.gain {
+				v = top.v
+				break
+			}
+		}
+		if v == -1 {
+			// Start again from any vertex if the region can't grow.
+			for side[scan] == 0 {
+				scan++
+			}
+			v = scan
+		}
+		side[v] = 0
+		weight += g.weight[v]
+		for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+			u := g.adj[k]
+			gain[u] += 2 * g.adjWeight[k]
+			if side[u] == 1 {
+				heap.Push(frontier, gainEntry{v: u, gain: gain[u]})
+			}
+		}
+	}
+	return side
+}
+
+// gainEntry is a frontier vertex and its gain when it was added.
+type gainEntry struct {
+	v, gain int
+}
+
+// gainHeap is a max-heap of frontier vertices by gain, with ties going to the
+// lowest vertex.
+type gainHeap []gainEntry
+
+func (h gainHeap) Len() int { return len(h) }
+func (h gainHeap) Less(i, j int) bool {
+	return h[i].gain > h[j].gain || h[i].gain == h[j].gain && h[i].v < h[j].v
+}
+func (h gainHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *gainHeap) Push(x interface{}) { *h = append(*h, x.(gainEntry)) }
+func (h *gainHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// cut returns the weight of the edges between the sides.
+func (g *graph) cut(side []int) int {
+	cut := 0
+	for v := range side {
+		for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+			if side[g.adj[k]] != side[v] {
+				cut += g.adjWeight[k]
+			}
+		}
+	}
+	return cut / 2
+}
+
+// refine improves the bisection by moving vertices on the cut to the other
+// side when that reduces the cut without breaking the balance, or when it
+// restores the balance. The balance also leaves each side at least the weight
+// in need.
+func (g *graph) refine(side []int, frac float64, need [2]int) {
+	total := float64(g.sumWeights)
+	target := [2]float64{frac * total, (1 - frac) * total}
+	var limit, weight [2]float64
+	for sd := range limit {
+		limit[sd] = target[sd]*(1+bisectTolerance) + float64(g.maxWeight)
+		if max := total - float64(need[1-sd]); limit[sd] > max {
+			limit[sd] = max
+		}
+	}
+	for v, sd := range side {
+		weight[sd] += float64(g.weight[v])
+	}
+	for pass := 0; pass < 10; pass++ {
+		moved := false
+		for v, from := range side {
+			to := 1 - from
+			internal, external := 0, 0
+			for k := g.offsets[v]; k < g.offsets[v+1]; k++ {
+				if side[g.adj[k]] == from {
+					internal += g.adjWeight[k]
+				} else {
+					external += g.adjWeight[k]
+				}
+			}
+			if external == 0 {
+				continue
+			}
+			w := float64(g.weight[v])
+			gain := external - internal
+			fits := weight[to]+w <= limit[to]
+			balances := weight[from]-target[from] > weight[to]+w-target[to]
+			overweight := weight[from] > limit[from]
+			if fits && (gain > 0 || gain == 0 && balances) || overweight && balances {
+				side[v] = to
+				weight[from] -= w
+				weight[to] += w
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+}
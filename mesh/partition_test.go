@@ -0,0 +1,89 @@
+package mesh
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPartition(t *testing.T) {
+	s := structuredQuads(40, 40)
+	if err := s.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		method  PartitionMethod
+		parts   int
+		maxCut  int
+		maxSkew float64
+	}{
+		{method: RCB, parts: 4, maxCut: 80, maxSkew: 1.0001},
+		{method: RCB, parts: 3, maxCut: 120, maxSkew: 1.01},
+		{method: Multilevel, parts: 4, maxCut: 120, maxSkew: 1.05},
+		{method: Multilevel, parts: 3, maxCut: 140, maxSkew: 1.05},
+	} {
+		p, err := s.Partition(test.parts, test.method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Points) != len(s.Points) || len(p.Elements) != len(s.Elements) {
+			t.Fatalf("method %d: length mismatch", test.method)
+		}
+		var points, elements int
+		for q := 0; q < test.parts; q++ {
+			points += p.PointCounts[q]
+			elements += p.ElementCounts[q]
+			if p.PointCounts[q] == 0 || p.GhostCounts[q] == 0 {
+				t.Errorf("method %d, %d parts: part %d has %d points and %d ghosts", test.method, test.parts, q, p.PointCounts[q], p.GhostCounts[q])
+			}
+		}
+		if points != len(s.Points) || elements != len(s.Elements) {
+			t.Errorf("method %d, %d parts: count mismatch", test.method, test.parts)
+		}
+		if p.Imbalance > test.maxSkew {
+			t.Errorf("method %d, %d parts: imbalance %v", test.method, test.parts, p.Imbalance)
+		}
+		if p.EdgeCut == 0 || p.EdgeCut > test.maxCut {
+			t.Errorf("method %d, %d parts: edge cut %d", test.method, test.parts, p.EdgeCut)
+		}
+		var b bytes.Buffer
+		if err := s.WriteVTU(&b, p.VTKData(), VTUASCII); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := s.Partition(1, Multilevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.EdgeCut != 0 || p.GhostCounts[0] != 0 {
+		t.Errorf("single part has edge cut %d and %d ghosts", p.EdgeCut, p.GhostCounts[0])
+	}
+	if _, err := s.Partition(0, RCB); err == nil {
+		t.Errorf("no error for zero parts")
+	}
+}
+
+func TestPartitionSmall(t *testing.T) {
+	// Small meshes split into nearly as many parts as points.
+	for ni := 2; ni <= 6; ni++ {
+		for nj := 2; nj <= ni; nj++ {
+			s := structuredQuads(ni, nj)
+			if err := s.initialize(); err != nil {
+				t.Fatal(err)
+			}
+			for n := 1; n <= len(s.Points); n++ {
+				for _, method := range []PartitionMethod{RCB, Multilevel} {
+					p, err := s.Partition(n, method)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for q, c := range p.PointCounts {
+						if c == 0 {
+							t.Errorf("method %d, %dx%d points into %d parts: part %d is empty", method, ni, nj, n, q)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package mesh
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Plot3D describes the layout of a Plot3D grid file and how to convert it to
+// an SU2 mesh.
+type Plot3D struct {
+	// Dim is the dimension of the grid, 2 or 3.
+	Dim int
+	// Unformatted selects Fortran unformatted files. Otherwise the file is
+	// formatted text.
+	Unformatted bool
+	// ByteOrder is the byte order of an unformatted file. If nil, it is
+	// detected from the first record marker.
+	ByteOrder binary.ByteOrder
+	// SingleBlock selects single-block files, which don't start with the
+	// number of blocks. Otherwise the file is multi-block.
+	SingleBlock bool
+	// Patches assign marker tags to block faces.
+	Patches []Patch
+	// Tol is the distance below which points on block faces are merged. If
+	// zero, it is 1e-10 times the size of the grid.
+	Tol float64
+}
+
+// ReadPlot3D reads a single or multi-block Plot3D grid. The blocks become
+// quadrilaterals in 2D and hexahedra in 3D, coincident points on block faces
+// are merged and the patches become markers. The coordinates of each block are
+// stored as all x, then all y, then all z values with i varying fastest. IBLANK
+// values in unformatted files are ignored.
+func ReadPlot3D(r io.Reader, p Plot3D) (*SU2, error) {
+	if p.Dim != 2 && p.Dim != 3 {
+		return nil, fmt.Errorf("plot3d: bad dimension %d", p.Dim)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []*block
+	if p.Unformatted {
+		blocks, err = p.readUnformatted(data)
+	} else {
+		blocks, err = p.readFormatted(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plot3d: %v", err)
+	}
+	return structuredMesh(blocks, p.Patches, p.Tol)
+}
+
+// newBlocks allocates the blocks from the list of dimensions. maxPoints is
+// the number of points the rest of the file can hold, so bad dimensions are
+// found before allocating.
+func (p Plot3D) newBlocks(dims []int, maxPoints int) ([]*block, error) {
+	blocks := make([]*block, len(dims)/p.Dim)
+	total := 0
+	for n := range blocks {
+		b := &block{ni: dims[n*p.Dim], nj: dims[n*p.Dim+1], nk: 1}
+		if p.Dim == 3 {
+			b.nk = dims[n*p.Dim+2]
+		}
+		if b.ni < 1 || b.nj < 1 || b.nk < 1 {
+			return nil, fmt.Errorf("block %d: bad dimensions %v", n, dims[n*p.Dim:(n+1)*p.Dim])
+		}
+		// Check as the size grows so the product can't overflow.
+		size := 1
+		for _, d := range []int{b.ni, b.nj, b.nk} {
+			if d > maxPoints || size*d > maxPoints {
+				return nil, fmt.Errorf("block %d: dimensions %v larger than the file", n, dims[n*p.Dim:(n+1)*p.Dim])
+			}
+			size *= d
+		}
+		total += size
+		if total > maxPoints {
+			return nil, fmt.Errorf("block %d: dimensions %v larger than the file", n, dims[n*p.Dim:(n+1)*p.Dim])
+		}
+		blocks[n] = b
+	}
+	for _, b := range blocks {
+		b.x = make([][]float64, b.ni*b.nj*b.nk)
+		for i := range b.x {
+			b.x[i] = make([]float64, p.Dim)
+		}
+	}
+	return blocks, nil
+}
+
+func (p Plot3D) readFormatted(data []byte) ([]*block, error) {
+	fields := strings.Fields(string(data))
+	// Fortran writes double precision exponents with a D
+	exponent := strings.NewReplacer("D", "E", "d", "e")
+	next := func() (float64, error) {
+		if len(fields) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		str := exponent.Replace(fields[0])
+		fields = fields[1:]
+		return strconv.ParseFloat(str, 64)
+	}
+	// count reads a count, which is at most the number of fields left.
+	count := func() (int, error) {
+		v, err := next()
+		if err != nil {
+			return 0, err
+		}
+		if v != math.Trunc(v) || v < 1 || v > float64(len(fields)) {
+			return 0, fmt.Errorf("bad count %v", v)
+		}
+		return int(v), nil
+	}
+	nBlocks := 1
+	if !p.SingleBlock {
+		var err error
+		nBlocks, err = count()
+		if err != nil {
+			return nil, fmt.Errorf("bad number of blocks: %v", err)
+		}
+	}
+	dims := make([]int, nBlocks*p.Dim)
+	for i := range dims {
+		var err error
+		dims[i], err = count()
+		if err != nil {
+			return nil, fmt.Errorf("bad block dimensions: %v", err)
+		}
+	}
+	blocks, err := p.newBlocks(dims, len(fields)/p.Dim)
+	if err != nil {
+		return nil, err
+	}
+	for n, b := range blocks {
+		for d := 0; d < p.Dim; d++ {
+			for i := range b.x {
+				b.x[i][d], err = next()
+				if err != nil {
+					return nil, fmt.Errorf("block %d: %v", n, err)
+				}
+			}
+		}
+	}
+	return blocks, nil
+}
+
+func (p Plot3D) readUnformatted(data []byte) ([]*block, error) {
+	order := p.ByteOrder
+	if order == nil {
+		if len(data) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		// A record marker is small, so a large value means the wrong order.
+		order = binary.LittleEndian
+		if binary.LittleEndian.Uint32(data) > 1<<20 {
+			order = binary.BigEndian
+		}
+	}
+	record := func() ([]byte, error) {
+		if len(data) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		n := int(order.Uint32(data))
+		if len(data) < n+8 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		rec := data[4 : 4+n]
+		if int(order.Uint32(data[4+n:])) != n {
+			return nil, errors.New("record markers don't match")
+		}
+		data = data[n+8:]
+		return rec, nil
+	}
+	ints := func(rec []byte) []int {
+		v := make([]int, len(rec)/4)
+		for i := range v {
+			v[i] = int(int32(order.Uint32(rec[4*i:])))
+		}
+		return v
+	}
+
+	rec, err := record()
+	if err != nil {
+		return nil, err
+	}
+	var dims []int
+	if !p.SingleBlock {
+		if len(rec) != 4 {
+			return nil, fmt.Errorf("block count record of %d bytes", len(rec))
+		}
+		nBlocks := ints(rec)[0]
+		rec, err = record()
+		if err != nil {
+			return nil, err
+		}
+		dims = ints(rec)
+		if len(dims) != nBlocks*p.Dim {
+			return nil, fmt.Errorf("%d block dimensions for %d blocks", len(dims), nBlocks)
+		}
+	} else {
+		dims = ints(rec)
+		if len(dims) != p.Dim {
+			return nil, fmt.Errorf("%d block dimensions in %dD", len(dims), p.Dim)
+		}
+	}
+	// Each coordinate takes at least 4 bytes.
+	blocks, err := p.newBlocks(dims, len(data)/(4*p.Dim))
+	if err != nil {
+		return nil, err
+	}
+	for n, b := range blocks {
+		rec, err := record()
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", n, err)
+		}
+		nPoints := len(b.x)
+		var size int
+		switch len(rec) {
+		case 8 * p.Dim * nPoints, (8*p.Dim + 4) * nPoints:
+			size = 8
+		case 4 * p.Dim * nPoints, (4*p.Dim + 4) * nPoints:
+			size = 4
+		default:
+			return nil, fmt.Errorf("block %d: record of %d bytes for %d points", n, len(rec), nPoints)
+		}
+		for d := 0; d < p.Dim; d++ {
+			for i := range b.x {
+				offset := size * (d*nPoints + i)
+				if size == 8 {
+					b.x[i][d] = math.Float64frombits(order.Uint64(rec[offset:]))
+				} else {
+					b.x[i][d] = float64(math.Float32frombits(order.Uint32(rec[offset:])))
+				}
+			}
+		}
+	}
+	return blocks, nil
+}
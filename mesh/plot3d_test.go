@@ -0,0 +1,129 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Two 3 by 2 blocks sharing the edge at x = 2. The second block runs in the
+// negative x direction.
+var plot3d2D = `2
+3 2
+3 2
+0 1 2 0 1 2
+0 0 0 1 1 1
+4.0D0 3.0D0 2.0D0 4.0D0 3.0D0 2.0D0
+0 0 0 1 1 1
+`
+
+func TestReadPlot3D(t *testing.T) {
+	s, err := ReadPlot3D(strings.NewReader(plot3d2D), Plot3D{
+		Dim: 2,
+		Patches: []Patch{
+			{Block: 0, Face: IMin, Tag: "inlet"},
+			{Block: 1, Face: IMin, Tag: "outlet"},
+			{Block: 0, Face: JMin, Tag: "wall"},
+			{Block: 1, Face: JMin, Tag: "wall"},
+			{Block: 0, Face: JMax, Tag: "top", Range: [2][2]int{{0, 1}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Points) != 10 || len(s.Elements) != 4 {
+		t.Fatalf("Size mismatch. Found %d points and %d elements", len(s.Points), len(s.Elements))
+	}
+	for _, elem := range s.Elements {
+		if elem.Type != Quadrilateral || s.Volume(elem) != 1 {
+			t.Errorf("bad element %v", elem)
+		}
+	}
+	for _, test := range []struct {
+		tag   string
+		count int
+	}{{"inlet", 1}, {"outlet", 1}, {"wall", 4}, {"top", 1}} {
+		marker := s.Marker(test.tag)
+		if marker == nil || len(marker.Elements) != test.count {
+			t.Errorf("Marker %s mismatch: %v", test.tag, marker)
+		}
+	}
+	normals, err := s.MarkerNormals(s.Marker("wall"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range normals {
+		if !closeTo(n, []float64{0, -1}, 1e-14) {
+			t.Errorf("Wall normal mismatch. Expected %v, found %v", []float64{0, -1}, n)
+		}
+	}
+
+	// The block count can share a line with the block dimensions.
+	for _, test := range []struct {
+		file   string
+		single bool
+	}{
+		{"1 3 2\n0 1 2 0 1 2\n0 0 0 1 1 1\n", false},
+		{"3 2\n0 1 2 0 1 2\n0 0 0 1 1 1\n", true},
+	} {
+		s, err := ReadPlot3D(strings.NewReader(test.file), Plot3D{Dim: 2, SingleBlock: test.single})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Points) != 6 || len(s.Elements) != 2 {
+			t.Errorf("Size mismatch. Found %d points and %d elements", len(s.Points), len(s.Elements))
+		}
+	}
+
+	for _, file := range []string{
+		"-1 3 2\n0 1 2 0 1 2\n0 0 0 1 1 1\n",
+		"1.5 3 2\n0 1 2 0 1 2\n0 0 0 1 1 1\n",
+		"1000000000 3 2\n0 1 2 0 1 2\n0 0 0 1 1 1\n",
+		"1 3 2000000000\n0 1 2 0 1 2\n0 0 0 1 1 1\n",
+		"1 3 -2\n0 1 2 0 1 2\n0 0 0 1 1 1\n",
+	} {
+		if _, err := ReadPlot3D(strings.NewReader(file), Plot3D{Dim: 2}); err == nil {
+			t.Errorf("no error for %q", file)
+		}
+	}
+
+	// A single unit cube block written as a multi-block unformatted file.
+	var buf bytes.Buffer
+	record := func(data interface{}) {
+		binary.Write(&buf, binary.BigEndian, uint32(binary.Size(data)))
+		binary.Write(&buf, binary.BigEndian, data)
+		binary.Write(&buf, binary.BigEndian, uint32(binary.Size(data)))
+	}
+	record([]int32{1})
+	record([]int32{2, 2, 2})
+	var x []float64
+	for d := 0; d < 3; d++ {
+		for p := 0; p < 8; p++ {
+			x = append(x, float64(p>>uint(d)&1))
+		}
+	}
+	record(x)
+	s, err = ReadPlot3D(&buf, Plot3D{
+		Dim:         3,
+		Unformatted: true,
+		Patches:     []Patch{{Block: 0, Face: KMax, Tag: "top"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Points) != 8 || len(s.Elements) != 1 || s.Elements[0].Type != Hexahedron {
+		t.Fatalf("Size mismatch. Found %d points and %d elements", len(s.Points), len(s.Elements))
+	}
+	if v := s.Volume(s.Elements[0]); math.Abs(v-1) > 1e-14 {
+		t.Errorf("Volume mismatch. Expected %v, found %v", 1, v)
+	}
+	normals, err = s.MarkerNormals(s.Marker("top"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closeTo(normals[0], []float64{0, 0, 1}, 1e-14) {
+		t.Errorf("Top normal mismatch. Expected %v, found %v", []float64{0, 0, 1}, normals[0])
+	}
+}
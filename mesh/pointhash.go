@@ -0,0 +1,81 @@
+package mesh
+
+import "math"
+
+// pointHash finds coincident points by hashing them into cells the size of the
+// tolerance, so only the neighboring cells need to be searched.
+type pointHash struct {
+	tol   float64
+	cells map[[3]int64][]int
+	x     [][]float64
+}
+
+func newPointHash(tol float64) *pointHash {
+	return &pointHash{
+		tol:   tol,
+		cells: make(map[[3]int64][]int),
+	}
+}
+
+func (h *pointHash) cell(x []float64) [3]int64 {
+	var c [3]int64
+	for i, v := range x {
+		c[i] = int64(math.Floor(v / h.tol))
+	}
+	return c
+}
+
+// find returns the index of a point within tol of x, or -1 if there is none.
+func (h *pointHash) find(x []float64) int {
+	c := h.cell(x)
+	best := -1
+	bestDist := h.tol
+	var offset [3]int64
+	for offset[0] = -1; offset[0] <= 1; offset[0]++ {
+		for offset[1] = -1; offset[1] <= 1; offset[1]++ {
+			for offset[2] = -1; offset[2] <= 1; offset[2]++ {
+				if len(x) < 3 && offset[2] != 0 {
+					continue
+				}
+				key := [3]int64{c[0] + offset[0], c[1] + offset[1], c[2] + offset[2]}
+				for _, i := range h.cells[key] {
+					if d := distance(x, h.x[i]); d <= bestDist {
+						best = i
+						bestDist = d
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// add adds the point and returns its index.
+func (h *pointHash) add(x []float64) int {
+	i := len(h.x)
+	h.x = append(h.x, x)
+	c := h.cell(x)
+	h.cells[c] = append(h.cells[c], i)
+	return i
+}
+
+// defaultTol returns a merging tolerance relative to the size of the bounding
+// box of the points.
+func defaultTol(x [][]float64) float64 {
+	if len(x) == 0 {
+		return 1e-10
+	}
+	lo := append([]float64(nil), x[0]...)
+	hi := append([]float64(nil), x[0]...)
+	for _, v := range x {
+		for i := range v {
+			lo[i] = math.Min(lo[i], v[i])
+			hi[i] = math.Max(hi[i], v[i])
+		}
+	}
+	size := distance(lo, hi)
+	if size == 0 {
+		return 1e-10
+	}
+	return 1e-10 * size
+}
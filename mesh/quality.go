@@ -0,0 +1,270 @@
+package mesh
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Quality holds the quality metrics of every element of a mesh, indexed by
+// ElementID.
+type Quality struct {
+	// Volume is the signed element volume. It is negative for inverted elements.
+	Volume []float64
+	// AspectRatio is the ratio of the longest to the shortest element edge.
+	AspectRatio []float64
+	// Skewness is the equiangle skewness, between 0 for equilateral faces and
+	// 1 for degenerate ones.
+	Skewness []float64
+	// NonOrthogonality is the largest angle in degrees between a face normal
+	// and the line joining the centroids of the elements sharing that face.
+	NonOrthogonality []float64
+	// VolumeRatio is the largest ratio between the volume of the element and
+	// the volume of a neighbor sharing one of its faces, or its inverse.
+	VolumeRatio []float64
+}
+
+// Quality computes the quality metrics of all of the elements of the mesh.
+func (s *SU2) Quality() *Quality {
+	n := len(s.Elements)
+	q := &Quality{
+		Volume:           make([]float64, n),
+		AspectRatio:      make([]float64, n),
+		Skewness:         make([]float64, n),
+		NonOrthogonality: make([]float64, n),
+		VolumeRatio:      make([]float64, n),
+	}
+	centroids := make([][]float64, n)
+	for i, elem := range s.Elements {
+		centroids[i] = s.Centroid(elem)
+		q.Volume[i] = s.Volume(elem)
+		q.AspectRatio[i] = s.aspectRatio(elem)
+		q.Skewness[i] = s.skewness(elem)
+		q.VolumeRatio[i] = 1
+	}
+	for _, refs := range s.faces() {
+		if len(refs) != 2 {
+			continue
+		}
+		a, b := refs[0].Element, refs[1].Element
+		normal := s.FaceNormals(s.Elements[a])[refs[0].Face]
+		d := sub(centroids[b], centroids[a])
+		cos := dot(normal, d) / (norm(normal) * norm(d))
+		angle := math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+		q.NonOrthogonality[a] = math.Max(q.NonOrthogonality[a], angle)
+		q.NonOrthogonality[b] = math.Max(q.NonOrthogonality[b], angle)
+
+		va, vb := math.Abs(q.Volume[a]), math.Abs(q.Volume[b])
+		ratio := math.Max(va/vb, vb/va)
+		q.VolumeRatio[a] = math.Max(q.VolumeRatio[a], ratio)
+		q.VolumeRatio[b] = math.Max(q.VolumeRatio[b], ratio)
+	}
+	return q
+}
+
+func (s *SU2) aspectRatio(e *Element) float64 {
+	min := math.Inf(1)
+	max := 0.0
+	for _, edge := range e.Type.Edges() {
+		l := distance(s.Points[e.VertexIds[edge[0]]].Location, s.Points[e.VertexIds[edge[1]]].Location)
+		min = math.Min(min, l)
+		max = math.Max(max, l)
+	}
+	return max / min
+}
+
+// skewness returns the equiangle skewness of the element, which is the largest
+// skewness of its faces in 3D.
+func (s *SU2) skewness(e *Element) float64 {
+	x := s.coords(e)
+	if e.Type.Dim() == 2 {
+		return polygonSkewness(x)
+	}
+	var skew float64
+	for _, face := range e.Type.Faces() {
+		fx := make([][]float64, len(face))
+		for i, local := range face {
+			fx[i] = x[local]
+		}
+		skew = math.Max(skew, polygonSkewness(fx))
+	}
+	return skew
+}
+
+// polygonSkewness returns the equiangle skewness of a triangle or
+// quadrilateral.
+func polygonSkewness(x [][]float64) float64 {
+	ideal := 180 * float64(len(x)-2) / float64(len(x))
+	min := 180.0
+	max := 0.0
+	for i := range x {
+		prev := sub(x[(i+len(x)-1)%len(x)], x[i])
+		next := sub(x[(i+1)%len(x)], x[i])
+		cos := dot(prev, next) / (norm(prev) * norm(next))
+		angle := math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+		min = math.Min(min, angle)
+		max = math.Max(max, angle)
+	}
+	return math.Max((max-ideal)/(180-ideal), (ideal-min)/ideal)
+}
+
+// QualityReport summarizes the quality of a mesh.
+type QualityReport struct {
+	NumElements      int
+	MinVolume        float64
+	MaxVolume        float64
+	Inverted         []ElementID // Elements with a negative volume
+	AspectRatio      MetricSummary
+	Skewness         MetricSummary
+	NonOrthogonality MetricSummary
+	VolumeRatio      MetricSummary
+}
+
+// MetricSummary summarizes a quality metric over the elements of a mesh. Min,
+// Max, Mean and Histogram only include the finite values, and are zero if
+// there are none.
+type MetricSummary struct {
+	Min       float64
+	Max       float64
+	Mean      float64
+	NonFinite int // The number of infinite or NaN values, from degenerate elements
+	Histogram Histogram
+	Worst     []ElementID // The elements with the largest values, worst first
+}
+
+// Histogram counts the values falling between consecutive bin edges. The last
+// bin includes its upper edge.
+type Histogram struct {
+	Edges  []float64
+	Counts []int
+}
+
+// defaultBins is the number of histogram bins used when Report is given none.
+const defaultBins = 10
+
+// Report summarizes the quality metrics using histograms with nBins bins, and
+// lists the nWorst worst elements for each metric. If nBins is not positive,
+// 10 bins are used. The volumes are zero for a mesh with no elements.
+func (q *Quality) Report(nBins, nWorst int) *QualityReport {
+	if nBins <= 0 {
+		nBins = defaultBins
+	}
+	r := &QualityReport{NumElements: len(q.Volume)}
+	for i, v := range q.Volume {
+		if i == 0 || v < r.MinVolume {
+			r.MinVolume = v
+		}
+		if i == 0 || v > r.MaxVolume {
+			r.MaxVolume = v
+		}
+		if v < 0 {
+			r.Inverted = append(r.Inverted, ElementID(i))
+		}
+	}
+	r.AspectRatio = summarize(q.AspectRatio, 1, math.NaN(), nBins, nWorst)
+	r.Skewness = summarize(q.Skewness, 0, 1, nBins, nWorst)
+	r.NonOrthogonality = summarize(q.NonOrthogonality, 0, 90, nBins, nWorst)
+	r.VolumeRatio = summarize(q.VolumeRatio, 1, math.NaN(), nBins, nWorst)
+	return r
+}
+
+// summarize computes the summary of the values with a histogram from lo to hi.
+// If hi is NaN the maximum finite value is used.
+func summarize(x []float64, lo, hi float64, nBins, nWorst int) MetricSummary {
+	var m MetricSummary
+	var finite int
+	for _, v := range x {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			m.NonFinite++
+			continue
+		}
+		if finite == 0 || v < m.Min {
+			m.Min = v
+		}
+		if finite == 0 || v > m.Max {
+			m.Max = v
+		}
+		m.Mean += v
+		finite++
+	}
+	if finite > 0 {
+		m.Mean /= float64(finite)
+	}
+	if math.IsNaN(hi) {
+		hi = m.Max
+	}
+	if hi <= lo {
+		hi = lo + 1
+	}
+
+	m.Histogram.Edges = make([]float64, nBins+1)
+	for i := range m.Histogram.Edges {
+		m.Histogram.Edges[i] = lo + (hi-lo)*float64(i)/float64(nBins)
+	}
+	m.Histogram.Counts = make([]int, nBins)
+	for _, v := range x {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			continue
+		}
+		bin := int((v - lo) / (hi - lo) * float64(nBins))
+		if bin < 0 {
+			bin = 0
+		}
+		if bin >= nBins {
+			bin = nBins - 1
+		}
+		m.Histogram.Counts[bin]++
+	}
+
+	// Non-finite values come from degenerate elements, so they are the worst.
+	key := func(v float64) float64 {
+		if math.IsNaN(v) {
+			return math.Inf(1)
+		}
+		return v
+	}
+	ids := make([]ElementID, len(x))
+	for i := range ids {
+		ids[i] = ElementID(i)
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return key(x[ids[i]]) > key(x[ids[j]]) })
+	if nWorst > len(ids) {
+		nWorst = len(ids)
+	}
+	if nWorst < 0 {
+		nWorst = 0
+	}
+	m.Worst = ids[:nWorst]
+	return m
+}
+
+func (r *QualityReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "elements: %d\n", r.NumElements)
+	fmt.Fprintf(&b, "volume: min %g, max %g\n", r.MinVolume, r.MaxVolume)
+	fmt.Fprintf(&b, "inverted elements: %d", len(r.Inverted))
+	if len(r.Inverted) > 0 {
+		fmt.Fprintf(&b, " %v", r.Inverted)
+	}
+	b.WriteByte('\n')
+	for _, metric := range []struct {
+		name string
+		m    *MetricSummary
+	}{
+		{"aspect ratio", &r.AspectRatio},
+		{"skewness", &r.Skewness},
+		{"non-orthogonality", &r.NonOrthogonality},
+		{"volume ratio", &r.VolumeRatio},
+	} {
+		m := metric.m
+		fmt.Fprintf(&b, "%s: min %g, max %g, mean %g, worst %v\n", metric.name, m.Min, m.Max, m.Mean, m.Worst)
+		if m.NonFinite > 0 {
+			fmt.Fprintf(&b, "\tnon-finite\t%d\n", m.NonFinite)
+		}
+		for i, count := range m.Histogram.Counts {
+			fmt.Fprintf(&b, "\t[%g, %g]\t%d\n", m.Histogram.Edges[i], m.Histogram.Edges[i+1], count)
+		}
+	}
+	return b.String()
+}
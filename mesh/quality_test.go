@@ -0,0 +1,82 @@
+package mesh
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestQuality(t *testing.T) {
+	s := readString(t, hybrid2D)
+	q := s.Quality()
+	if math.Abs(q.AspectRatio[0]-2) > 1e-14 {
+		t.Errorf("Aspect ratio mismatch. Expected %v, found %v", 2, q.AspectRatio[0])
+	}
+	if math.Abs(q.Skewness[0]) > 1e-14 {
+		t.Errorf("Skewness mismatch. Expected %v, found %v", 0, q.Skewness[0])
+	}
+	wantSkew := (60 - math.Atan(0.5)*180/math.Pi) / 60
+	if math.Abs(q.Skewness[1]-wantSkew) > 1e-12 {
+		t.Errorf("Skewness mismatch. Expected %v, found %v", wantSkew, q.Skewness[1])
+	}
+	wantAngle := math.Atan(0.4) * 180 / math.Pi
+	if math.Abs(q.NonOrthogonality[0]-wantAngle) > 1e-12 {
+		t.Errorf("Non-orthogonality mismatch. Expected %v, found %v", wantAngle, q.NonOrthogonality[0])
+	}
+	if math.Abs(q.VolumeRatio[0]-2) > 1e-14 {
+		t.Errorf("Volume ratio mismatch. Expected %v, found %v", 2, q.VolumeRatio[0])
+	}
+
+	r := q.Report(10, 2)
+	if len(r.Inverted) != 0 {
+		t.Errorf("Found inverted elements %v", r.Inverted)
+	}
+	if r.Skewness.Worst[0] != 1 && r.Skewness.Worst[0] != 2 {
+		t.Errorf("Worst skewness is element %v", r.Skewness.Worst[0])
+	}
+	var count int
+	for _, c := range r.AspectRatio.Histogram.Counts {
+		count += c
+	}
+	if count != 3 {
+		t.Errorf("Histogram count mismatch. Expected %v, found %v", 3, count)
+	}
+
+	ids := s.Elements[2].VertexIds
+	ids[0], ids[1] = ids[1], ids[0]
+	r = s.Quality().Report(10, 2)
+	if len(r.Inverted) != 1 || r.Inverted[0] != 2 {
+		t.Errorf("Inverted mismatch. Expected %v, found %v", []ElementID{2}, r.Inverted)
+	}
+}
+
+func TestQualityReportDegenerate(t *testing.T) {
+	s := readString(t, hybrid2D)
+	ids := s.Elements[2].VertexIds
+	s.Points[ids[1]].Location = append([]float64(nil), s.Points[ids[0]].Location...)
+	r := s.Quality().Report(0, 1)
+	if len(r.AspectRatio.Histogram.Counts) != defaultBins {
+		t.Errorf("Bin count mismatch. Expected %v, found %v", defaultBins, len(r.AspectRatio.Histogram.Counts))
+	}
+	if r.AspectRatio.NonFinite != 1 || r.AspectRatio.Worst[0] != 2 {
+		t.Errorf("Degenerate element not reported: %+v", r.AspectRatio)
+	}
+	for _, e := range r.AspectRatio.Histogram.Edges {
+		if math.IsInf(e, 0) || math.IsNaN(e) {
+			t.Errorf("Non-finite histogram edges %v", r.AspectRatio.Histogram.Edges)
+			break
+		}
+	}
+	var count int
+	for _, c := range r.AspectRatio.Histogram.Counts {
+		count += c
+	}
+	if count != 2 {
+		t.Errorf("Histogram count mismatch. Expected %v, found %v", 2, count)
+	}
+
+	empty := (&Quality{}).Report(5, 2)
+	if _, err := json.Marshal(empty); err != nil {
+		t.Errorf("Empty report does not serialize: %v", err)
+	}
+}
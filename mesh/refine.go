@@ -0,0 +1,431 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The children of the elements split by Refine. Each child vertex is the
+// center of a set of vertices of the parent: a vertex, an edge midpoint, a face
+// center or the element center.
+var (
+	refineLine = [][][]int{
+		{{0}, {0, 1}},
+		{{0, 1}, {1}},
+	}
+	refineTriangle = [][][]int{
+		{{0}, {0, 1}, {2, 0}},
+		{{0, 1}, {1}, {1, 2}},
+		{{2, 0}, {1, 2}, {2}},
+		{{0, 1}, {1, 2}, {2, 0}},
+	}
+	refineQuadrilateral = [][][]int{
+		{{0}, {0, 1}, {0, 1, 2, 3}, {3, 0}},
+		{{0, 1}, {1}, {1, 2}, {0, 1, 2, 3}},
+		{{0, 1, 2, 3}, {1, 2}, {2}, {2, 3}},
+		{{3, 0}, {0, 1, 2, 3}, {2, 3}, {3}},
+	}
+	// The corner tetrahedra. The octahedron left in the middle is split along
+	// its shortest diagonal.
+	refineTetrahedron = [][][]int{
+		{{0}, {0, 1}, {0, 2}, {0, 3}},
+		{{0, 1}, {1}, {1, 2}, {1, 3}},
+		{{0, 2}, {1, 2}, {2}, {2, 3}},
+		{{0, 3}, {1, 3}, {2, 3}, {3}},
+	}
+	refineHexahedron = hexChildren()
+	refinePrism      = prismChildren()
+	// Pyramids are split into six pyramids, the first four at the corners of
+	// the base and one of them upside down, and four tetrahedra.
+	refinePyramid = [][][]int{
+		{{0}, {0, 1}, {0, 1, 2, 3}, {3, 0}, {0, 4}},
+		{{0, 1}, {1}, {1, 2}, {0, 1, 2, 3}, {1, 4}},
+		{{0, 1, 2, 3}, {1, 2}, {2}, {2, 3}, {2, 4}},
+		{{3, 0}, {0, 1, 2, 3}, {2, 3}, {3}, {3, 4}},
+		{{0, 4}, {1, 4}, {2, 4}, {3, 4}, {4}},
+		{{0, 4}, {3, 4}, {2, 4}, {1, 4}, {0, 1, 2, 3}},
+		{{0, 1}, {0, 1, 2, 3}, {0, 4}, {1, 4}},
+		{{1, 2}, {0, 1, 2, 3}, {1, 4}, {2, 4}},
+		{{2, 3}, {0, 1, 2, 3}, {2, 4}, {3, 4}},
+		{{3, 0}, {0, 1, 2, 3}, {3, 4}, {0, 4}},
+	}
+)
+
+// hexChildren returns the eight children of a hexahedron from the 3x3x3
+// lattice of its vertices, edge midpoints, face centers and center.
+func hexChildren() [][][]int {
+	corners := [8][3]int{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}}
+	// set returns the hexahedron vertices surrounding the lattice point.
+	set := func(p [3]int) []int {
+		var s []int
+		for v, c := range corners {
+			match := true
+			for d := 0; d < 3; d++ {
+				if p[d]%2 == 0 && c[d] != p[d]/2 {
+					match = false
+				}
+			}
+			if match {
+				s = append(s, v)
+			}
+		}
+		return s
+	}
+	var children [][][]int
+	for _, child := range corners {
+		var sets [][]int
+		for _, c := range corners {
+			sets = append(sets, set([3]int{child[0] + c[0], child[1] + c[1], child[2] + c[2]}))
+		}
+		children = append(children, sets)
+	}
+	return children
+}
+
+// prismChildren returns the eight children of a prism, made of the triangle
+// children of its base in two layers.
+func prismChildren() [][][]int {
+	// set returns the prism vertices surrounding a triangle lattice point at
+	// the bottom (0), middle (1) or top (2) level.
+	set := func(tri []int, level int) []int {
+		var s []int
+		for _, v := range tri {
+			if level < 2 {
+				s = append(s, v)
+			}
+			if level > 0 {
+				s = append(s, v+3)
+			}
+		}
+		return s
+	}
+	var children [][][]int
+	for level := 0; level < 2; level++ {
+		for _, tri := range refineTriangle {
+			var sets [][]int
+			for _, l := range []int{level, level + 1} {
+				for _, v := range tri {
+					sets = append(sets, set(v, l))
+				}
+			}
+			children = append(children, sets)
+		}
+	}
+	return children
+}
+
+// refiner builds the refined mesh. New points at the centers of edges and
+// faces are shared between the elements and markers that use them.
+type refiner struct {
+	s       *SU2
+	out     *SU2
+	centers map[faceKey]PointID
+}
+
+// center returns the point at the center of the points, adding it if needed.
+func (r *refiner) center(ids []PointID) PointID {
+	if len(ids) == 1 {
+		return ids[0]
+	}
+	var key faceKey
+	if len(ids) <= len(key) {
+		key = newFaceKey(ids)
+		if id, ok := r.centers[key]; ok {
+			return id
+		}
+	}
+	x := make([][]float64, len(ids))
+	for i, id := range ids {
+		x[i] = r.s.Points[id].Location
+	}
+	id := PointID(len(r.out.Points))
+	r.out.Points = append(r.out.Points, &Point{Id: id, Location: average(x)})
+	if len(ids) <= len(key) {
+		r.centers[key] = id
+	}
+	return id
+}
+
+// children returns the vertices of the children of the element for the
+// pattern of vertex sets.
+func (r *refiner) children(elem *Element, pattern [][][]int) [][]PointID {
+	var children [][]PointID
+	ids := make([]PointID, 0, 8)
+	// The center of a hexahedron belongs to it alone.
+	cell := PointID(-1)
+	for _, sets := range pattern {
+		child := make([]PointID, len(sets))
+		for i, set := range sets {
+			if len(set) == len(elem.VertexIds) && len(set) > len(faceKey{}) {
+				if cell == -1 {
+					cell = r.center(elem.VertexIds)
+				}
+				child[i] = cell
+				continue
+			}
+			ids = ids[:0]
+			for _, local := range set {
+				ids = append(ids, elem.VertexIds[local])
+			}
+			child[i] = r.center(ids)
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// split returns the children of the element.
+func (r *refiner) split(elem *Element) ([]*Element, error) {
+	var pattern [][][]int
+	switch elem.Type {
+	case Line:
+		pattern = refineLine
+	case Triangle:
+		pattern = refineTriangle
+	case Quadrilateral:
+		pattern = refineQuadrilateral
+	case Tetrahedron:
+		pattern = refineTetrahedron
+	case Hexahedron:
+		pattern = refineHexahedron
+	case Prism:
+		pattern = refinePrism
+	case Pyramid:
+		pattern = refinePyramid
+	default:
+		return nil, fmt.Errorf("can't refine %v", elem.Type)
+	}
+	var children []*Element
+	for _, vertices := range r.children(elem, pattern) {
+		typ := elem.Type
+		if typ == Pyramid && len(vertices) == 4 {
+			typ = Tetrahedron
+		}
+		children = append(children, &Element{Type: typ, VertexIds: vertices})
+	}
+	if elem.Type == Tetrahedron {
+		children = append(children, r.octahedron(elem)...)
+	}
+	return children, nil
+}
+
+// octahedron returns the four tetrahedra filling the octahedron between the
+// edge midpoints of a tetrahedron, split along its shortest diagonal.
+func (r *refiner) octahedron(elem *Element) []*Element {
+	edges := elem.Type.Edges()
+	mid := make([]PointID, len(edges))
+	for i, e := range edges {
+		mid[i] = r.center([]PointID{elem.VertexIds[e[0]], elem.VertexIds[e[1]]})
+	}
+	// The diagonals join the midpoints of opposite edges, which share no
+	// vertex.
+	shares := func(a, b int) bool {
+		return edges[a][0] == edges[b][0] || edges[a][0] == edges[b][1] ||
+			edges[a][1] == edges[b][0] || edges[a][1] == edges[b][1]
+	}
+	best := [2]int{-1, -1}
+	var bestLength float64
+	for a := range edges {
+		for b := a + 1; b < len(edges); b++ {
+			if shares(a, b) {
+				continue
+			}
+			d := distance(r.out.Points[mid[a]].Location, r.out.Points[mid[b]].Location)
+			if best[0] == -1 || d < bestLength {
+				best, bestLength = [2]int{a, b}, d
+			}
+		}
+	}
+	// The other midpoints form a ring around the diagonal, where neighbors
+	// share a vertex.
+	var ring []int
+	used := map[int]bool{best[0]: true, best[1]: true}
+	for len(ring) < 4 {
+		for e := range edges {
+			if used[e] || (len(ring) > 0 && !shares(e, ring[len(ring)-1])) {
+				continue
+			}
+			ring = append(ring, e)
+			used[e] = true
+			break
+		}
+	}
+	var tets []*Element
+	for i := range ring {
+		tets = append(tets, &Element{
+			Type:      Tetrahedron,
+			VertexIds: []PointID{mid[best[0]], mid[best[1]], mid[ring[i]], mid[ring[(i+1)%4]]},
+		})
+	}
+	return tets
+}
+
+// Refine returns the mesh with every element split into 2^Dim children by
+// adding points at the midpoints of the edges and at the centers of
+// quadrilateral faces and hexahedra. Prisms are split into 8 prisms and
+// pyramids into 6 pyramids and 4 tetrahedra. The marker elements are split to
+// match. The original points keep their ids, and the children of each element
+// are numbered consecutively.
+func (s *SU2) Refine() (*SU2, error) {
+	r := &refiner{
+		s:       s,
+		out:     &SU2{Dim: s.Dim, SkipNeighbors: s.SkipNeighbors},
+		centers: make(map[faceKey]PointID),
+	}
+	for _, point := range s.Points {
+		r.out.Points = append(r.out.Points, &Point{
+			Id:       point.Id,
+			Location: append([]float64(nil), point.Location...),
+		})
+	}
+	for _, elem := range s.Elements {
+		children, err := r.split(elem)
+		if err != nil {
+			return nil, fmt.Errorf("refine: element %d: %v", elem.Id, err)
+		}
+		// Children are oriented like the parent.
+		parent := s.Volume(elem)
+		for _, child := range children {
+			child.Id = ElementID(len(r.out.Elements))
+			if parent*r.out.Volume(child) < 0 {
+				flip(child)
+			}
+			r.out.Elements = append(r.out.Elements, child)
+		}
+	}
+	for _, marker := range s.Markers {
+		refined := &Marker{Tag: marker.Tag}
+		for i := range marker.Elements {
+			elem := &marker.Elements[i]
+			children, err := r.split(elem)
+			if err != nil {
+				return nil, fmt.Errorf("refine: marker %s element %d: %v", marker.Tag, i, err)
+			}
+			for _, child := range children {
+				child.Id = elem.Id
+				refined.Elements = append(refined.Elements, *child)
+			}
+		}
+		r.out.Markers = append(r.out.Markers, refined)
+	}
+	if err := r.out.initialize(); err != nil {
+		return nil, err
+	}
+	return r.out, nil
+}
+
+// CoarsenStructured returns the mesh with every other grid line removed. The
+// mesh must be a structured grid of quadrilaterals or hexahedra with the given
+// number of points in each direction, such as the meshes built by FlatPlate or
+// ReadPlot3D from a single block. Points must be numbered with the first
+// direction varying fastest, and there must be an odd number of points in each
+// direction. The marker elements are merged to match.
+func (s *SU2) CoarsenStructured(dims []int) (*SU2, error) {
+	if len(dims) != s.Dim {
+		return nil, fmt.Errorf("coarsen: %d dimensions in %dD", len(dims), s.Dim)
+	}
+	d := [3]int{1, 1, 1}
+	for i, v := range dims {
+		if v < 3 || v%2 == 0 {
+			return nil, fmt.Errorf("coarsen: need an odd number of at least 3 points, have %v", dims)
+		}
+		d[i] = v
+	}
+	n := d[0] * d[1] * d[2]
+	if n != len(s.Points) {
+		return nil, fmt.Errorf("coarsen: %d points for dimensions %v", len(s.Points), dims)
+	}
+	lattice := func(id PointID) [3]int {
+		p := int(id)
+		return [3]int{p % d[0], p / d[0] % d[1], p / (d[0] * d[1])}
+	}
+	var c [3]int
+	for i := range c {
+		c[i] = (d[i] + 1) / 2
+	}
+	coarse := func(p [3]int) PointID {
+		return PointID(p[0]/2 + c[0]*(p[1]/2+c[1]*(p[2]/2)))
+	}
+
+	out := &SU2{Dim: s.Dim, SkipNeighbors: s.SkipNeighbors}
+	for k := 0; k < d[2]; k += 2 {
+		for j := 0; j < d[1]; j += 2 {
+			for i := 0; i < d[0]; i += 2 {
+				point := s.Points[i+d[0]*(j+d[1]*k)]
+				out.Points = append(out.Points, &Point{
+					Id:       PointID(len(out.Points)),
+					Location: append([]float64(nil), point.Location...),
+				})
+			}
+		}
+	}
+
+	// Each coarse element or marker element replaces the fine ones in a
+	// 2x2(x2) group of cells, and is made from the fine one at the lowest
+	// corner by moving its vertices to the far side of the group.
+	coarsen := func(elem *Element) (*Element, bool, error) {
+		var lo, hi [3]int
+		for i, id := range elem.VertexIds {
+			p := lattice(id)
+			for dir := range p {
+				if i == 0 || p[dir] < lo[dir] {
+					lo[dir] = p[dir]
+				}
+				if i == 0 || p[dir] > hi[dir] {
+					hi[dir] = p[dir]
+				}
+			}
+		}
+		for dir := range lo {
+			if hi[dir]-lo[dir] > 1 {
+				return nil, false, errors.New("not a cell of the structured grid")
+			}
+			if lo[dir]%2 == 1 {
+				return nil, false, nil
+			}
+		}
+		vertices := make([]PointID, len(elem.VertexIds))
+		for i, id := range elem.VertexIds {
+			p := lattice(id)
+			for dir := range p {
+				p[dir] = lo[dir] + 2*(p[dir]-lo[dir])
+			}
+			vertices[i] = coarse(p)
+		}
+		return &Element{Id: elem.Id, Type: elem.Type, VertexIds: vertices}, true, nil
+	}
+	want := Quadrilateral
+	if s.Dim == 3 {
+		want = Hexahedron
+	}
+	for _, elem := range s.Elements {
+		if elem.Type != want {
+			return nil, fmt.Errorf("coarsen: element %d is a %v", elem.Id, elem.Type)
+		}
+		coarseElem, ok, err := coarsen(elem)
+		if err != nil {
+			return nil, fmt.Errorf("coarsen: element %d: %v", elem.Id, err)
+		}
+		if ok {
+			coarseElem.Id = ElementID(len(out.Elements))
+			out.Elements = append(out.Elements, coarseElem)
+		}
+	}
+	for _, marker := range s.Markers {
+		coarseMarker := &Marker{Tag: marker.Tag}
+		for i := range marker.Elements {
+			coarseElem, ok, err := coarsen(&marker.Elements[i])
+			if err != nil {
+				return nil, fmt.Errorf("coarsen: marker %s element %d: %v", marker.Tag, i, err)
+			}
+			if ok {
+				coarseMarker.Elements = append(coarseMarker.Elements, *coarseElem)
+			}
+		}
+		out.Markers = append(out.Markers, coarseMarker)
+	}
+	if err := out.initialize(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package mesh
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestRefine(t *testing.T) {
+	for _, test := range []struct {
+		file           string
+		points         int
+		boundary       bool
+		markerElements map[string]int
+	}{
+		{file: hybrid2D, points: 15, markerElements: map[string]int{"lower": 4, "upper": 4}},
+		{file: hybrid3D, boundary: true},
+	} {
+		s := readString(t, test.file)
+		if test.boundary {
+			addBoundaryMarker(s, "boundary")
+			test.markerElements = map[string]int{"boundary": 4 * len(s.Markers[0].Elements)}
+		}
+		fine, err := s.Refine()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.points != 0 && len(fine.Points) != test.points {
+			t.Errorf("%dD: point mismatch. Expected %d, found %d", s.Dim, test.points, len(fine.Points))
+		}
+		var want int
+		for _, elem := range s.Elements {
+			want += 1 << uint(s.Dim)
+			if elem.Type == Pyramid {
+				want += 2
+			}
+		}
+		if len(fine.Elements) != want {
+			t.Errorf("%dD: element mismatch. Expected %d, found %d", s.Dim, want, len(fine.Elements))
+		}
+		var volume, fineVolume float64
+		for _, elem := range s.Elements {
+			volume += s.Volume(elem)
+		}
+		for _, elem := range fine.Elements {
+			v := fine.Volume(elem)
+			if v <= 0 {
+				t.Errorf("%dD: element %d has volume %v", s.Dim, elem.Id, v)
+			}
+			fineVolume += v
+		}
+		if math.Abs(volume-fineVolume) > 1e-14 {
+			t.Errorf("%dD: volume mismatch. Expected %v, found %v", s.Dim, volume, fineVolume)
+		}
+		for tag, n := range test.markerElements {
+			marker := fine.Marker(tag)
+			if marker == nil || len(marker.Elements) != n {
+				t.Fatalf("%dD: marker %s mismatch: %v", s.Dim, tag, marker)
+			}
+			// Every marker element must be an outward face of the mesh.
+			normals, err := fine.MarkerNormals(marker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range marker.Elements {
+				if n := fine.Normal(&marker.Elements[i]); !closeTo(n, normals[i], 1e-14) {
+					t.Errorf("%dD marker %s element %d: not outward", s.Dim, tag, i)
+				}
+			}
+		}
+		if test.boundary {
+			// The refined mesh must be conforming, so its boundary is the
+			// refined boundary.
+			var boundary int
+			for _, refs := range fine.faces() {
+				if len(refs) == 1 {
+					boundary++
+				}
+			}
+			if boundary != test.markerElements["boundary"] {
+				t.Errorf("Boundary face mismatch. Expected %d, found %d", test.markerElements["boundary"], boundary)
+			}
+		}
+	}
+}
+
+func TestCoarsenStructured(t *testing.T) {
+	f, err := os.Open("mesh_flatplate_turb_137x97.su2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := &SU2{SkipNeighbors: true}
+	if _, err := s.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+	coarse, err := s.CoarsenStructured([]int{137, 97})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coarse.Points) != 69*49 || len(coarse.Elements) != 68*48 {
+		t.Fatalf("Size mismatch. Found %d points and %d elements", len(coarse.Points), len(coarse.Elements))
+	}
+	var area, coarseArea float64
+	for _, elem := range s.Elements {
+		area += s.Volume(elem)
+	}
+	for _, elem := range coarse.Elements {
+		coarseArea += coarse.Volume(elem)
+	}
+	if math.Abs(area-coarseArea) > 1e-12 {
+		t.Errorf("Area mismatch. Expected %v, found %v", area, coarseArea)
+	}
+	for _, test := range []struct {
+		tag   string
+		count int
+	}{{"farfield", 68}, {"inlet", 48}, {"outlet", 48}, {"symmetry", 12}, {"wall", 56}} {
+		marker := coarse.Marker(test.tag)
+		if marker == nil || len(marker.Elements) != test.count {
+			t.Errorf("Marker %s mismatch: %v", test.tag, marker)
+		}
+	}
+
+	quads := structuredQuads(3, 5)
+	if err := quads.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quads.CoarsenStructured([]int{3, 4}); err == nil {
+		t.Errorf("no error for even dimensions")
+	}
+	if _, err := s.CoarsenStructured([]int{97, 137}); err == nil {
+		t.Errorf("no error for wrong dimensions")
+	}
+}
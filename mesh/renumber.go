@@ -0,0 +1,168 @@
+package mesh
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Bandwidth returns the bandwidth and profile of the matrix with the sparsity
+// of the point graph. The bandwidth is the largest difference between the ids
+// of connected points, and the profile is the sum over all points of the
+// difference between its id and the smallest id it is connected to.
+func (a *Adjacency) Bandwidth() (bandwidth, profile int) {
+	for p := 0; p < a.NumPoints(); p++ {
+		neighbors := a.Neighbors(PointID(p))
+		if len(neighbors) == 0 {
+			continue
+		}
+		// Neighbors are in increasing order.
+		if d := int(neighbors[len(neighbors)-1]) - p; d > bandwidth {
+			bandwidth = d
+		}
+		if d := p - int(neighbors[0]); d > 0 {
+			profile += d
+		}
+	}
+	return bandwidth, profile
+}
+
+// RenumberReport is the bandwidth and profile of the point graph before and
+// after renumbering.
+type RenumberReport struct {
+	BandwidthBefore, BandwidthAfter int
+	ProfileBefore, ProfileAfter     int
+}
+
+func (r *RenumberReport) String() string {
+	return fmt.Sprintf("bandwidth: %d -> %d\nprofile: %d -> %d\n",
+		r.BandwidthBefore, r.BandwidthAfter, r.ProfileBefore, r.ProfileAfter)
+}
+
+// RenumberRCM renumbers the points in reverse Cuthill-McKee order, which
+// reduces the bandwidth of the matrices SU2 builds on the mesh.
+func (s *SU2) RenumberRCM() (*RenumberReport, error) {
+	adj := s.Adjacency()
+	r := &RenumberReport{}
+	r.BandwidthBefore, r.ProfileBefore = adj.Bandwidth()
+	if err := s.Renumber(adj.RCM()); err != nil {
+		return nil, err
+	}
+	r.BandwidthAfter, r.ProfileAfter = s.Adjacency().Bandwidth()
+	return r, nil
+}
+
+// RCM returns the reverse Cuthill-McKee ordering of the points, where
+// order[i] is the point to be numbered i. Each connected component is
+// numbered in breadth first order from a pseudo-peripheral point, visiting
+// neighbors by increasing degree, and the whole order is reversed.
+func (a *Adjacency) RCM() []PointID {
+	n := a.NumPoints()
+	order := make([]PointID, 0, n)
+	visited := make([]bool, n)
+	for p := 0; p < n; p++ {
+		if visited[p] {
+			continue
+		}
+		start := a.peripheral(PointID(p))
+		visited[start] = true
+		order = append(order, start)
+		for i := len(order) - 1; i < len(order); i++ {
+			first := len(order)
+			for _, q := range a.Neighbors(order[i]) {
+				if !visited[q] {
+					visited[q] = true
+					order = append(order, q)
+				}
+			}
+			next := order[first:]
+			sort.SliceStable(next, func(i, j int) bool {
+				return a.Degree(next[i]) < a.Degree(next[j])
+			})
+		}
+	}
+	for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// peripheral finds a pseudo-peripheral point of the component containing p
+// with the algorithm of George and Liu. Starting from p, it moves to the
+// point of smallest degree in the last level of the breadth first search
+// while that increases the number of levels.
+func (a *Adjacency) peripheral(p PointID) PointID {
+	level := make(map[PointID]int)
+	levels := func(root PointID) (int, []PointID) {
+		for k := range level {
+			delete(level, k)
+		}
+		level[root] = 0
+		queue := []PointID{root}
+		for i := 0; i < len(queue); i++ {
+			for _, q := range a.Neighbors(queue[i]) {
+				if _, ok := level[q]; !ok {
+					level[q] = level[queue[i]] + 1
+					queue = append(queue, q)
+				}
+			}
+		}
+		depth := level[queue[len(queue)-1]]
+		last := len(queue)
+		for last > 0 && level[queue[last-1]] == depth {
+			last--
+		}
+		return depth, queue[last:]
+	}
+	depth, last := levels(p)
+	for {
+		next := last[0]
+		for _, q := range last {
+			if a.Degree(q) < a.Degree(next) {
+				next = q
+			}
+		}
+		d, l := levels(next)
+		if d <= depth {
+			return p
+		}
+		p, depth, last = next, d, l
+	}
+}
+
+// Renumber renumbers the points so that point order[i] becomes point i. The
+// element and marker vertices are updated to the new numbering, and the
+// neighbors are rebuilt.
+func (s *SU2) Renumber(order []PointID) error {
+	if len(order) != len(s.Points) {
+		return fmt.Errorf("renumber: order of length %d for %d points", len(order), len(s.Points))
+	}
+	newID := make([]PointID, len(order))
+	for i := range newID {
+		newID[i] = -1
+	}
+	for i, old := range order {
+		if old < 0 || int(old) >= len(order) || newID[old] != -1 {
+			return fmt.Errorf("renumber: order is not a permutation at %d", i)
+		}
+		newID[old] = PointID(i)
+	}
+	points := make([]*Point, len(order))
+	for i, old := range order {
+		points[i] = s.Points[old]
+		points[i].Id = PointID(i)
+	}
+	s.Points = points
+	for _, elem := range s.Elements {
+		for i, id := range elem.VertexIds {
+			elem.VertexIds[i] = newID[id]
+		}
+	}
+	for _, marker := range s.Markers {
+		for _, elem := range marker.Elements {
+			for i, id := range elem.VertexIds {
+				elem.VertexIds[i] = newID[id]
+			}
+		}
+	}
+	return s.initialize()
+}
@@ -0,0 +1,79 @@
+package mesh
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRenumberRCM(t *testing.T) {
+	s := structuredQuads(30, 8)
+	order := make([]PointID, len(s.Points))
+	for i, p := range rand.New(rand.NewSource(1)).Perm(len(order)) {
+		order[i] = PointID(p)
+	}
+	if err := s.Renumber(order); err != nil {
+		t.Fatal(err)
+	}
+	var area float64
+	for _, elem := range s.Elements {
+		area += s.Volume(elem)
+	}
+	r, err := s.RenumberRCM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.BandwidthAfter >= r.BandwidthBefore || r.ProfileAfter >= r.ProfileBefore {
+		t.Errorf("no improvement: %v", r)
+	}
+	// The ordering by rows across the short side has a bandwidth of 8.
+	if r.BandwidthAfter > 9 {
+		t.Errorf("bandwidth %d too large", r.BandwidthAfter)
+	}
+	var after float64
+	for _, elem := range s.Elements {
+		v := s.Volume(elem)
+		if math.Abs(v-1) > 1e-14 {
+			t.Errorf("element %d: area %v", elem.Id, v)
+		}
+		after += v
+	}
+	if after != area {
+		t.Errorf("area mismatch. Expected %v, found %v", area, after)
+	}
+	for i, point := range s.Points {
+		if point.Id != PointID(i) {
+			t.Errorf("point %d has id %d", i, point.Id)
+		}
+	}
+
+	s = readString(t, hybrid2D)
+	locations := make(map[string][][]float64)
+	for _, marker := range s.Markers {
+		for _, elem := range marker.Elements {
+			for _, id := range elem.VertexIds {
+				locations[marker.Tag] = append(locations[marker.Tag], s.Points[id].Location)
+			}
+		}
+	}
+	if _, err := s.RenumberRCM(); err != nil {
+		t.Fatal(err)
+	}
+	for _, marker := range s.Markers {
+		var i int
+		for _, elem := range marker.Elements {
+			for _, id := range elem.VertexIds {
+				if !closeTo(s.Points[id].Location, locations[marker.Tag][i], 0) {
+					t.Errorf("marker %s: point mismatch", marker.Tag)
+				}
+				i++
+			}
+		}
+	}
+	if err := s.Renumber(make([]PointID, len(s.Points))); err == nil {
+		t.Errorf("no error for repeated points")
+	}
+	if err := s.Renumber(order[:3]); err == nil {
+		t.Errorf("no error for wrong length")
+	}
+}
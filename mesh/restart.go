@@ -0,0 +1,131 @@
+package mesh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Restart is an SU2 ASCII restart or solution file. Each line holds the
+// values of the fields at one point, after the point index.
+type Restart struct {
+	// Fields are the names of the columns after PointID, for example "x",
+	// "y", "Density" and "Momentum_x". They are empty if the file has no
+	// header.
+	Fields []string
+	// Data holds the values of the fields at each point.
+	Data [][]float64
+	// Metadata are the lines after the point data, such as "EXT_ITER= 100"
+	// and "AOA= 2.0". SU2 reads them to restart the iteration count and the
+	// flow angles.
+	Metadata []string
+}
+
+// ReadRestart reads an SU2 ASCII restart file. The points must be in order.
+func ReadRestart(r io.Reader) (*Restart, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	res := &Restart{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		str := strings.TrimSpace(scanner.Text())
+		if str == "" {
+			continue
+		}
+		if line == 1 && strings.HasPrefix(str, `"`) {
+			for _, name := range strings.Split(str, "\t") {
+				res.Fields = append(res.Fields, strings.Trim(strings.TrimSpace(name), `"`))
+			}
+			if len(res.Fields) == 0 || res.Fields[0] != "PointID" {
+				return nil, errors.New("restart: header doesn't start with PointID")
+			}
+			res.Fields = res.Fields[1:]
+			continue
+		}
+		if len(res.Metadata) > 0 || strings.Contains(str, "=") {
+			res.Metadata = append(res.Metadata, str)
+			continue
+		}
+		strs := strings.Fields(str)
+		id, err := strconv.Atoi(strs[0])
+		if err != nil {
+			return nil, fmt.Errorf("restart: line %d: bad point index: %v", line, err)
+		}
+		if id != len(res.Data) {
+			return nil, fmt.Errorf("restart: line %d: point %d out of order", line, id)
+		}
+		row := make([]float64, len(strs)-1)
+		for i, s := range strs[1:] {
+			row[i], err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("restart: line %d: %v", line, err)
+			}
+		}
+		if len(res.Data) > 0 && len(row) != len(res.Data[0]) {
+			return nil, fmt.Errorf("restart: line %d: %d values, expected %d", line, len(row), len(res.Data[0]))
+		}
+		res.Data = append(res.Data, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if res.Fields != nil && len(res.Data) > 0 && len(res.Fields) != len(res.Data[0]) {
+		return nil, fmt.Errorf("restart: %d fields in header and %d values per point", len(res.Fields), len(res.Data[0]))
+	}
+	return res, nil
+}
+
+// WriteTo writes the restart file in the format read by ReadRestart and by
+// SU2 with RESTART_SOL= YES.
+func (r *Restart) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &countWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	if r.Fields != nil {
+		buf.WriteString(`"PointID"`)
+		for _, name := range r.Fields {
+			buf.WriteString("\t\"" + name + "\"")
+		}
+		buf.WriteByte('\n')
+	}
+	for i, row := range r.Data {
+		buf.WriteString(strconv.Itoa(i))
+		for _, v := range row {
+			buf.WriteByte('\t')
+			buf.WriteString(strconv.FormatFloat(v, 'e', 15, 64))
+		}
+		buf.WriteByte('\n')
+	}
+	for _, line := range r.Metadata {
+		buf.WriteString(line + "\n")
+	}
+	err = buf.Flush()
+	return cw.n, err
+}
+
+// Transfer moves the restart solution from the source mesh to the target
+// mesh with t. The coordinate fields x, y and z are set to the target point
+// locations rather than interpolated. The metadata is kept.
+func (r *Restart) Transfer(source, target *SU2, t Transfer) (*Restart, error) {
+	data, _, err := t.Interpolate(source, r.Data, target)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range r.Fields {
+		d := strings.Index("xyz", name)
+		if len(name) != 1 || d == -1 || d >= target.Dim {
+			continue
+		}
+		for p, row := range data {
+			row[i] = target.Points[p].Location[d]
+		}
+	}
+	return &Restart{
+		Fields:   append([]string(nil), r.Fields...),
+		Data:     data,
+		Metadata: append([]string(nil), r.Metadata...),
+	}, nil
+}
@@ -0,0 +1,204 @@
+package mesh
+
+import "math"
+
+// Shape returns the values of the linear shape functions of the element type
+// at the parametric coordinates xi, one per vertex. The shape functions
+// interpolate values at the vertices, and sum to one.
+//
+// The parametric coordinates follow VTK. Lines, quadrilaterals and hexahedra
+// span the unit interval in each coordinate. Triangles and tetrahedra use the
+// barycentric coordinates of vertices 1, 2 and 3, so vertex 0 has weight
+// 1-ξ-η-ζ. Prisms are a triangle in ξ and η extruded in ζ, and pyramids have
+// a unit square base with the apex at ζ = 1.
+func (v VTKType) Shape(xi []float64) []float64 {
+	n, _ := v.shape(xi)
+	return n
+}
+
+// shape returns the shape functions of the element type at xi, and their
+// derivatives with respect to each parametric coordinate.
+func (v VTKType) shape(xi []float64) (n []float64, dn [][]float64) {
+	switch v {
+	case Line:
+		r := xi[0]
+		return []float64{1 - r, r}, [][]float64{{-1}, {1}}
+	case Triangle:
+		r, s := xi[0], xi[1]
+		return []float64{1 - r - s, r, s},
+			[][]float64{{-1, -1}, {1, 0}, {0, 1}}
+	case Quadrilateral:
+		r, s := xi[0], xi[1]
+		return []float64{(1 - r) * (1 - s), r * (1 - s), r * s, (1 - r) * s},
+			[][]float64{{s - 1, r - 1}, {1 - s, -r}, {s, r}, {-s, 1 - r}}
+	case Tetrahedron:
+		r, s, t := xi[0], xi[1], xi[2]
+		return []float64{1 - r - s - t, r, s, t},
+			[][]float64{{-1, -1, -1}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	case Hexahedron:
+		r, s, t := xi[0], xi[1], xi[2]
+		n = make([]float64, 8)
+		dn = make([][]float64, 8)
+		for i := range n {
+			// Vertices 0 to 3 are the bottom face, counterclockwise from the
+			// origin, and 4 to 7 the top face.
+			a, b, c := float64((i+1)/2%2), float64(i/2%2), float64(i/4)
+			fr, fs, ft := 1-a+(2*a-1)*r, 1-b+(2*b-1)*s, 1-c+(2*c-1)*t
+			n[i] = fr * fs * ft
+			dn[i] = []float64{(2*a - 1) * fs * ft, fr * (2*b - 1) * ft, fr * fs * (2*c - 1)}
+		}
+		return n, dn
+	case Prism:
+		r, s, t := xi[0], xi[1], xi[2]
+		u := 1 - r - s
+		return []float64{u * (1 - t), r * (1 - t), s * (1 - t), u * t, r * t, s * t},
+			[][]float64{
+				{t - 1, t - 1, -u}, {1 - t, 0, -r}, {0, 1 - t, -s},
+				{-t, -t, u}, {t, 0, r}, {0, t, s},
+			}
+	case Pyramid:
+		r, s, t := xi[0], xi[1], xi[2]
+		return []float64{
+				(1 - r) * (1 - s) * (1 - t), r * (1 - s) * (1 - t),
+				r * s * (1 - t), (1 - r) * s * (1 - t), t,
+			},
+			[][]float64{
+				{-(1 - s) * (1 - t), -(1 - r) * (1 - t), -(1 - r) * (1 - s)},
+				{(1 - s) * (1 - t), -r * (1 - t), -r * (1 - s)},
+				{s * (1 - t), r * (1 - t), -r * s},
+				{-s * (1 - t), (1 - r) * (1 - t), -(1 - r) * s},
+				{0, 0, 1},
+			}
+	}
+	return nil, nil
+}
+
+// parametricCenter returns the parametric coordinates of the middle of the
+// element type.
+func parametricCenter(v VTKType) []float64 {
+	switch v {
+	case Line:
+		return []float64{0.5}
+	case Triangle:
+		return []float64{1.0 / 3, 1.0 / 3}
+	case Quadrilateral:
+		return []float64{0.5, 0.5}
+	case Tetrahedron:
+		return []float64{0.25, 0.25, 0.25}
+	case Prism:
+		return []float64{1.0 / 3, 1.0 / 3, 0.5}
+	case Pyramid:
+		return []float64{0.5, 0.5, 0.2}
+	}
+	return []float64{0.5, 0.5, 0.5}
+}
+
+// insideParametric returns true if the parametric coordinates are in the
+// element, within tol.
+func insideParametric(v VTKType, xi []float64, tol float64) bool {
+	for _, c := range xi {
+		if c < -tol || c > 1+tol {
+			return false
+		}
+	}
+	switch v {
+	case Triangle, Prism:
+		return xi[0]+xi[1] <= 1+tol
+	case Tetrahedron:
+		return xi[0]+xi[1]+xi[2] <= 1+tol
+	}
+	return true
+}
+
+// parametric returns the parametric coordinates of the location x in the
+// element, found by Newton iteration on the shape functions, and whether x is
+// inside the element. The element must have the dimension of the mesh.
+func (s *SU2) parametric(e *Element, x []float64) ([]float64, bool) {
+	vertices := s.coords(e)
+	xi := parametricCenter(e.Type)
+	dim := len(xi)
+	size := 0.0
+	for _, v := range vertices[1:] {
+		size = math.Max(size, distance(v, vertices[0]))
+	}
+	jac := make([][]float64, dim)
+	for i := range jac {
+		jac[i] = make([]float64, dim)
+	}
+	r := make([]float64, dim)
+	for iter := 0; iter < 20; iter++ {
+		n, dn := e.Type.shape(xi)
+		copy(r, x)
+		for i := range jac {
+			for j := range jac[i] {
+				jac[i][j] = 0
+			}
+		}
+		for k, v := range vertices {
+			for i := 0; i < dim; i++ {
+				r[i] -= n[k] * v[i]
+				for j := 0; j < dim; j++ {
+					jac[i][j] += dn[k][j] * v[i]
+				}
+			}
+		}
+		if norm(r) <= 1e-13*size {
+			break
+		}
+		dxi, ok := solveLinear(jac, r)
+		if !ok {
+			return xi, false
+		}
+		for i := range xi {
+			xi[i] += dxi[i]
+		}
+		if norm(dxi) < 1e-14 {
+			break
+		}
+	}
+	// solveLinear overwrites r, so find the residual at the final xi.
+	n, _ := e.Type.shape(xi)
+	copy(r, x)
+	for k, v := range vertices {
+		for i := 0; i < dim; i++ {
+			r[i] -= n[k] * v[i]
+		}
+	}
+	return xi, norm(r) <= 1e-9*size && insideParametric(e.Type, xi, 1e-10)
+}
+
+// solveLinear solves the small linear system a x = b by Gaussian elimination
+// with partial pivoting. It returns false if a is singular. a and b are
+// modified.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		if a[p][k] == 0 {
+			return nil, false
+		}
+		a[k], a[p] = a[p], a[k]
+		b[k], b[p] = b[p], b[k]
+		for i := k + 1; i < n; i++ {
+			f := a[i][k] / a[k][k]
+			for j := k; j < n; j++ {
+				a[i][j] -= f * a[k][j]
+			}
+			b[i] -= f * b[k]
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := b[i]
+		for j := i + 1; j < n; j++ {
+			sum -= a[i][j] * x[j]
+		}
+		x[i] = sum / a[i][i]
+	}
+	return x, true
+}
@@ -0,0 +1,99 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+)
+
+// SpatialIndex answers spatial queries on the points and elements of a mesh.
+// It must be rebuilt if the points move.
+type SpatialIndex struct {
+	s        *SU2
+	points   *kdTree
+	elements *boxTree
+	tol      float64
+}
+
+// SpatialIndex builds a k-d tree of the points and a box tree of the elements.
+func (s *SU2) SpatialIndex() *SpatialIndex {
+	x := make([][]float64, len(s.Points))
+	for i, point := range s.Points {
+		x[i] = point.Location
+	}
+	lo := make([][]float64, len(s.Elements))
+	hi := make([][]float64, len(s.Elements))
+	for i, elem := range s.Elements {
+		lo[i], hi[i] = bounds(s.coords(elem))
+	}
+	return &SpatialIndex{
+		s:        s,
+		points:   newKDTree(x),
+		elements: newBoxTree(lo, hi),
+		tol:      defaultTol(x),
+	}
+}
+
+// Nearest returns the point closest to x and its distance, or -1 and +Inf
+// if the mesh has no points. Nearest, KNearest and Radius panic if the length
+// of x isn't the mesh dimension.
+func (idx *SpatialIndex) Nearest(x []float64) (PointID, float64) {
+	idx.checkDim(x)
+	n := idx.points.nearest(x, 1)
+	if len(n) == 0 {
+		return -1, math.Inf(1)
+	}
+	return PointID(n[0].i), n[0].d
+}
+
+// KNearest returns the k points closest to x in order of increasing distance,
+// and their distances.
+func (idx *SpatialIndex) KNearest(x []float64, k int) ([]PointID, []float64) {
+	idx.checkDim(x)
+	return neighborIds(idx.points.nearest(x, k))
+}
+
+// Radius returns the points within distance r of x in order of increasing
+// distance, and their distances.
+func (idx *SpatialIndex) Radius(x []float64, r float64) ([]PointID, []float64) {
+	idx.checkDim(x)
+	return neighborIds(idx.points.radius(x, r))
+}
+
+func (idx *SpatialIndex) checkDim(x []float64) {
+	if len(x) != idx.s.Dim {
+		panic(fmt.Sprintf("mesh: %d coordinates in a %dD mesh", len(x), idx.s.Dim))
+	}
+}
+
+func neighborIds(n []neighbor) ([]PointID, []float64) {
+	ids := make([]PointID, len(n))
+	dist := make([]float64, len(n))
+	for i, v := range n {
+		ids[i], dist[i] = PointID(v.i), v.d
+	}
+	return ids, dist
+}
+
+// Locate returns the element containing x and the parametric coordinates of x
+// in it, or false if x is outside the mesh. For triangles and tetrahedra the
+// parametric coordinates are barycentric. Use Element.Type.Shape to get the
+// weights of the element vertices at x. Points on a face shared by two
+// elements are located in either one, and x with the wrong dimension is
+// outside the mesh.
+func (idx *SpatialIndex) Locate(x []float64) (ElementID, []float64, bool) {
+	if len(x) != idx.s.Dim {
+		return -1, nil, false
+	}
+	var found ElementID
+	var xi []float64
+	ok := idx.elements.find(x, idx.tol, func(item int) bool {
+		var inside bool
+		xi, inside = idx.s.parametric(idx.s.Elements[item], x)
+		found = ElementID(item)
+		return inside
+	})
+	if !ok {
+		return -1, nil, false
+	}
+	return found, xi, true
+}
@@ -0,0 +1,156 @@
+package mesh
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// parametricVertices are the parametric coordinates of the vertices of each
+// element type.
+var parametricVertices = map[VTKType][][]float64{
+	Line:          {{0}, {1}},
+	Triangle:      {{0, 0}, {1, 0}, {0, 1}},
+	Quadrilateral: {{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+	Tetrahedron:   {{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	Hexahedron: {
+		{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0},
+		{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1},
+	},
+	Prism:   {{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0, 1}, {0, 1, 1}},
+	Pyramid: {{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0.5, 0.5, 1}},
+}
+
+func TestShape(t *testing.T) {
+	for typ, vertices := range parametricVertices {
+		for i, xi := range vertices {
+			for j, v := range typ.Shape(xi) {
+				want := 0.0
+				if i == j {
+					want = 1
+				}
+				if math.Abs(v-want) > 1e-14 {
+					t.Errorf("%v vertex %d: shape %d is %v", typ, i, j, v)
+				}
+			}
+		}
+		xi := parametricCenter(typ)
+		n, dn := typ.shape(xi)
+		var sum float64
+		for _, v := range n {
+			sum += v
+		}
+		if math.Abs(sum-1) > 1e-14 {
+			t.Errorf("%v: shape functions sum to %v", typ, sum)
+		}
+		// Compare the derivatives to central differences.
+		const h = 1e-6
+		for d := range xi {
+			plus := append([]float64(nil), xi...)
+			minus := append([]float64(nil), xi...)
+			plus[d] += h
+			minus[d] -= h
+			np, nm := typ.Shape(plus), typ.Shape(minus)
+			for k := range n {
+				if fd := (np[k] - nm[k]) / (2 * h); math.Abs(fd-dn[k][d]) > 1e-8 {
+					t.Errorf("%v: derivative %d of shape %d mismatch. Expected %v, found %v", typ, d, k, fd, dn[k][d])
+				}
+			}
+		}
+	}
+}
+
+func TestSpatialIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, file := range []string{hybrid2D, hybrid3D} {
+		s := readString(t, file)
+		// Rotate so that the element faces aren't aligned with the tree boxes.
+		center := make([]float64, s.Dim)
+		var axis []float64
+		if s.Dim == 3 {
+			axis = []float64{1, 2, 3}
+		}
+		if err := s.Rotate(center, axis, 30); err != nil {
+			t.Fatal(err)
+		}
+		idx := s.SpatialIndex()
+
+		for trial := 0; trial < 20; trial++ {
+			x := make([]float64, s.Dim)
+			for i := range x {
+				x[i] = 3*rnd.Float64() - 1
+			}
+			type pd struct {
+				id PointID
+				d  float64
+			}
+			all := make([]pd, len(s.Points))
+			for i, point := range s.Points {
+				all[i] = pd{PointID(i), distance(x, point.Location)}
+			}
+			sort.Slice(all, func(i, j int) bool { return all[i].d < all[j].d })
+			if id, d := idx.Nearest(x); id != all[0].id || d != all[0].d {
+				t.Errorf("%dD: nearest mismatch. Expected %v, found %v %v", s.Dim, all[0], id, d)
+			}
+			ids, _ := idx.KNearest(x, 4)
+			for i, id := range ids {
+				if id != all[i].id {
+					t.Errorf("%dD: k-nearest mismatch. Expected %v, found %v", s.Dim, all[:4], ids)
+					break
+				}
+			}
+			r := all[len(all)/2].d
+			ids, dist := idx.Radius(x, r)
+			if len(ids) != len(all)/2+1 || dist[len(dist)-1] != r {
+				t.Errorf("%dD: radius mismatch. Found %v", s.Dim, ids)
+			}
+		}
+
+		for _, elem := range s.Elements {
+			vertices := s.coords(elem)
+			for trial := 0; trial < 5; trial++ {
+				// A random point strictly inside the element
+				xi := make([]float64, s.Dim)
+				for {
+					for i := range xi {
+						xi[i] = 0.05 + 0.9*rnd.Float64()
+					}
+					if insideParametric(elem.Type, xi, -0.05) {
+						break
+					}
+				}
+				x := make([]float64, s.Dim)
+				for k, n := range elem.Type.Shape(xi) {
+					for i := range x {
+						x[i] += n * vertices[k][i]
+					}
+				}
+				id, found, ok := idx.Locate(x)
+				if !ok || id != elem.Id {
+					t.Errorf("%dD: located %v in %d, expected %d", s.Dim, x, id, elem.Id)
+					continue
+				}
+				if !closeTo(found, xi, 1e-10) {
+					t.Errorf("%v: parametric mismatch. Expected %v, found %v", elem.Type, xi, found)
+				}
+			}
+		}
+		outside := make([]float64, s.Dim)
+		outside[0] = -1
+		if _, _, ok := idx.Locate(outside); ok {
+			t.Errorf("%dD: located point outside the mesh", s.Dim)
+		}
+		if _, _, ok := idx.Locate(outside[:1]); ok {
+			t.Errorf("%dD: located a 1D point", s.Dim)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%dD: no panic for a 1D point", s.Dim)
+				}
+			}()
+			idx.Nearest(outside[:1])
+		}()
+	}
+}
@@ -0,0 +1,276 @@
+package mesh
+
+import (
+	"fmt"
+)
+
+// BlockFace is a face of a structured block.
+type BlockFace int
+
+const (
+	IMin BlockFace = iota
+	IMax
+	JMin
+	JMax
+	KMin
+	KMax
+)
+
+// Patch assigns a marker tag to the cells on a face of a structured block.
+type Patch struct {
+	Block int
+	Face  BlockFace
+	Tag   string
+	// Range optionally restricts the patch to the cells between two point
+	// indices along each direction of the face, in i, j, k order. In 2D only
+	// Range[0] is used. A zero Range covers the whole face.
+	Range [2][2]int
+}
+
+// block is a structured grid of ni by nj by nk points, stored with i varying
+// fastest. nk is 1 for 2D blocks.
+type block struct {
+	ni, nj, nk int
+	x          [][]float64
+}
+
+func (b *block) dims() [3]int {
+	return [3]int{b.ni, b.nj, b.nk}
+}
+
+func (b *block) index(i, j, k int) int {
+	return i + b.ni*(j+b.nj*k)
+}
+
+// onFace returns true if the point is on a face of the block.
+func (b *block) onFace(i, j, k int) bool {
+	return i == 0 || i == b.ni-1 || j == 0 || j == b.nj-1 ||
+		(b.nk > 1 && (k == 0 || k == b.nk-1))
+}
+
+// structuredMesh builds a mesh of quadrilaterals or hexahedra from the blocks.
+// Points on block faces closer than tol are merged, and the patches become
+// markers. Elements are reordered where needed so none are inverted.
+func structuredMesh(blocks []*block, patches []Patch, tol float64) (*SU2, error) {
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no blocks")
+	}
+	dim := 3
+	if blocks[0].nk == 1 {
+		dim = 2
+	}
+	if tol <= 0 {
+		var all [][]float64
+		for _, b := range blocks {
+			all = append(all, b.x...)
+		}
+		tol = defaultTol(all)
+	}
+
+	s := &SU2{Dim: dim}
+	hash := newPointHash(tol)
+	ids := make([][]PointID, len(blocks))
+	for n, b := range blocks {
+		if (b.nk == 1) != (dim == 2) {
+			return nil, fmt.Errorf("block %d: mixed 2D and 3D blocks", n)
+		}
+		if b.ni < 2 || b.nj < 2 || (dim == 3 && b.nk < 2) {
+			return nil, fmt.Errorf("block %d: too few points", n)
+		}
+		ids[n] = make([]PointID, len(b.x))
+		for k := 0; k < b.nk; k++ {
+			for j := 0; j < b.nj; j++ {
+				for i := 0; i < b.ni; i++ {
+					p := b.index(i, j, k)
+					x := b.x[p]
+					if b.onFace(i, j, k) {
+						if found := hash.find(x); found != -1 {
+							ids[n][p] = PointID(found)
+							continue
+						}
+						ids[n][p] = PointID(hash.add(x))
+					} else {
+						// Interior points are never merged, but they are
+						// stored in the hash to keep the numbering.
+						ids[n][p] = PointID(len(hash.x))
+						hash.x = append(hash.x, x)
+					}
+				}
+			}
+		}
+	}
+	s.Points = make([]*Point, len(hash.x))
+	for i, x := range hash.x {
+		s.Points[i] = &Point{
+			Id:       PointID(i),
+			Location: append([]float64(nil), x...),
+		}
+	}
+
+	offsets := make([]int, len(blocks))
+	for n, b := range blocks {
+		offsets[n] = len(s.Elements)
+		nk := b.nk - 1
+		if dim == 2 {
+			nk = 1
+		}
+		for k := 0; k < nk; k++ {
+			for j := 0; j < b.nj-1; j++ {
+				for i := 0; i < b.ni-1; i++ {
+					elem := &Element{Id: ElementID(len(s.Elements))}
+					corners := [][3]int{{i, j, k}, {i + 1, j, k}, {i + 1, j + 1, k}, {i, j + 1, k}}
+					elem.Type = Quadrilateral
+					if dim == 3 {
+						elem.Type = Hexahedron
+						for _, c := range corners[:4] {
+							corners = append(corners, [3]int{c[0], c[1], k + 1})
+						}
+					}
+					for _, c := range corners {
+						elem.VertexIds = append(elem.VertexIds, ids[n][b.index(c[0], c[1], c[2])])
+					}
+					if err := checkDistinct(elem); err != nil {
+						return nil, fmt.Errorf("block %d cell (%d, %d, %d): %v", n, i, j, k, err)
+					}
+					if s.Volume(elem) < 0 {
+						flip(elem)
+					}
+					s.Elements = append(s.Elements, elem)
+				}
+			}
+		}
+	}
+
+	for _, patch := range patches {
+		if err := addPatch(s, blocks, ids, offsets, patch); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.initialize(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkDistinct returns an error if the element has repeated vertices.
+func checkDistinct(elem *Element) error {
+	for i, a := range elem.VertexIds {
+		for _, b := range elem.VertexIds[i+1:] {
+			if a == b {
+				return fmt.Errorf("degenerate cell with repeated point %d", a)
+			}
+		}
+	}
+	return nil
+}
+
+// addPatch adds the cell faces of the patch to the marker with its tag.
+func addPatch(s *SU2, blocks []*block, ids [][]PointID, offsets []int, patch Patch) error {
+	if patch.Block < 0 || patch.Block >= len(blocks) {
+		return fmt.Errorf("patch %s: no block %d", patch.Tag, patch.Block)
+	}
+	b := blocks[patch.Block]
+	dims := b.dims()
+	normal := int(patch.Face) / 2
+	if normal >= s.Dim {
+		return fmt.Errorf("patch %s: no face %d in %dD", patch.Tag, patch.Face, s.Dim)
+	}
+	side := 0
+	if patch.Face%2 == 1 {
+		side = dims[normal] - 1
+	}
+	// The directions along the face and the cell ranges in each
+	var dirs []int
+	for d := 0; d < s.Dim; d++ {
+		if d != normal {
+			dirs = append(dirs, d)
+		}
+	}
+	var lo, hi [2]int
+	for n, d := range dirs {
+		lo[n], hi[n] = patch.Range[n][0], patch.Range[n][1]
+		if patch.Range == [2][2]int{} {
+			lo[n], hi[n] = 0, dims[d]-1
+		}
+		if lo[n] < 0 || hi[n] > dims[d]-1 || lo[n] >= hi[n] {
+			return fmt.Errorf("patch %s: bad range %v", patch.Tag, patch.Range[n])
+		}
+	}
+	if len(dirs) == 1 {
+		lo[1], hi[1] = 0, 1
+	}
+
+	marker := s.Marker(patch.Tag)
+	if marker == nil {
+		marker = &Marker{Tag: patch.Tag}
+		s.Markers = append(s.Markers, marker)
+	}
+	cells := [3]int{dims[0] - 1, dims[1] - 1, dims[2] - 1}
+	if s.Dim == 2 {
+		cells[2] = 1
+	}
+	for u := lo[0]; u < hi[0]; u++ {
+		for v := lo[1]; v < hi[1]; v++ {
+			var cell [3]int
+			cell[dirs[0]] = u
+			if len(dirs) == 2 {
+				cell[dirs[1]] = v
+			}
+			cell[normal] = 0
+			if side > 0 {
+				cell[normal] = side - 1
+			}
+			e := offsets[patch.Block] + cell[0] + cells[0]*(cell[1]+cells[1]*cell[2])
+			elem := s.Elements[e]
+
+			// The points of the face are the cell corners on the face
+			onFace := make(map[PointID]bool)
+			var corner [3]int
+			for du := 0; du < 2; du++ {
+				for dv := 0; dv < len(dirs); dv++ {
+					corner = cell
+					corner[normal] = side
+					corner[dirs[0]] += du
+					if len(dirs) == 2 {
+						corner[dirs[1]] += dv
+					}
+					onFace[ids[patch.Block][b.index(corner[0], corner[1], corner[2])]] = true
+				}
+			}
+			face := elementFace(elem, onFace)
+			if face == nil {
+				return fmt.Errorf("patch %s: face not found", patch.Tag)
+			}
+			marker.Elements = append(marker.Elements, Element{
+				Id:        -1,
+				Type:      faceType(len(face)),
+				VertexIds: face,
+			})
+		}
+	}
+	return nil
+}
+
+// elementFace returns the vertices of the face of the element made of the
+// given points, ordered with the normal pointing out of the element, or nil if
+// there is no such face.
+func elementFace(elem *Element, points map[PointID]bool) []PointID {
+	for _, face := range elem.Type.Faces() {
+		if len(face) != len(points) {
+			continue
+		}
+		ids := make([]PointID, len(face))
+		match := true
+		for i, local := range face {
+			ids[i] = elem.VertexIds[local]
+			if !points[ids[i]] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return ids
+		}
+	}
+	return nil
+}
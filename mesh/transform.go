@@ -0,0 +1,157 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// flipOrders reorders the vertices of each element type to reverse its
+// orientation.
+var flipOrders = map[VTKType][]int{
+	Line:          {1, 0},
+	Triangle:      {0, 2, 1},
+	Quadrilateral: {0, 3, 2, 1},
+	Tetrahedron:   {0, 2, 1, 3},
+	Hexahedron:    {0, 3, 2, 1, 4, 7, 6, 5},
+	Prism:         {0, 2, 1, 3, 5, 4},
+	Pyramid:       {0, 3, 2, 1, 4},
+}
+
+// Scale multiplies all point coordinates by factor, which must be positive.
+// This is the same as the MESH_SCALE_CHANGE option of SU2.
+func (s *SU2) Scale(factor float64) error {
+	if !(factor > 0) || math.IsInf(factor, 1) {
+		return fmt.Errorf("mesh: bad scale factor %v", factor)
+	}
+	for _, point := range s.Points {
+		scale(point.Location, factor)
+	}
+	return nil
+}
+
+// Translate moves all points by offset.
+func (s *SU2) Translate(offset []float64) error {
+	if len(offset) != s.Dim {
+		return fmt.Errorf("mesh: offset of length %d in %dD", len(offset), s.Dim)
+	}
+	for _, point := range s.Points {
+		for i, v := range offset {
+			point.Location[i] += v
+		}
+	}
+	return nil
+}
+
+// Rotate rotates all points by the angle in degrees about the axis through
+// center, following the right-hand rule. In 2D the axis must be nil and the
+// rotation is counterclockwise about center.
+func (s *SU2) Rotate(center, axis []float64, degrees float64) error {
+	if len(center) != s.Dim {
+		return fmt.Errorf("mesh: center of length %d in %dD", len(center), s.Dim)
+	}
+	theta := degrees * math.Pi / 180
+	c, sin := math.Cos(theta), math.Sin(theta)
+	var rot [][]float64
+	if s.Dim == 2 {
+		if axis != nil {
+			return errors.New("mesh: rotation axis in 2D")
+		}
+		rot = [][]float64{{c, -sin}, {sin, c}}
+	} else {
+		if len(axis) != 3 || norm(axis) == 0 {
+			return fmt.Errorf("mesh: bad rotation axis %v", axis)
+		}
+		k := scale(append([]float64(nil), axis...), 1/norm(axis))
+		// Rodrigues' rotation formula
+		rot = make([][]float64, 3)
+		for i := range rot {
+			rot[i] = make([]float64, 3)
+			for j := range rot[i] {
+				rot[i][j] = (1 - c) * k[i] * k[j]
+				if i == j {
+					rot[i][j] += c
+				}
+			}
+		}
+		rot[0][1] -= sin * k[2]
+		rot[0][2] += sin * k[1]
+		rot[1][0] += sin * k[2]
+		rot[1][2] -= sin * k[0]
+		rot[2][0] -= sin * k[1]
+		rot[2][1] += sin * k[0]
+	}
+	s.transform(rot, center)
+	return nil
+}
+
+// Mirror reflects all points across the plane, or the line in 2D, through
+// point with the given normal. Mirroring reverses the handedness of the mesh,
+// so the vertices of the elements and markers are reordered to keep them
+// positively oriented and the marker normals pointing out of the domain.
+func (s *SU2) Mirror(point, normal []float64) error {
+	if len(point) != s.Dim || len(normal) != s.Dim || norm(normal) == 0 {
+		return fmt.Errorf("mesh: bad mirror plane through %v with normal %v", point, normal)
+	}
+	// Check the elements before changing anything.
+	for _, elem := range s.Elements {
+		if order, ok := flipOrders[elem.Type]; !ok || len(order) != len(elem.VertexIds) {
+			return fmt.Errorf("mesh: cannot mirror element %d of type %v with %d vertices", elem.Id, elem.Type, len(elem.VertexIds))
+		}
+	}
+	for _, marker := range s.Markers {
+		for i, elem := range marker.Elements {
+			if order, ok := flipOrders[elem.Type]; !ok || len(order) != len(elem.VertexIds) {
+				return fmt.Errorf("mesh: cannot mirror marker %s element %d of type %v with %d vertices", marker.Tag, i, elem.Type, len(elem.VertexIds))
+			}
+		}
+	}
+	n := scale(append([]float64(nil), normal...), 1/norm(normal))
+	ref := make([][]float64, s.Dim)
+	for i := range ref {
+		ref[i] = make([]float64, s.Dim)
+		for j := range ref[i] {
+			ref[i][j] = -2 * n[i] * n[j]
+			if i == j {
+				ref[i][j]++
+			}
+		}
+	}
+	s.transform(ref, point)
+	for _, elem := range s.Elements {
+		flip(elem)
+	}
+	for _, marker := range s.Markers {
+		for i := range marker.Elements {
+			flip(&marker.Elements[i])
+		}
+	}
+	return nil
+}
+
+// transform applies the linear map m about center to all points.
+func (s *SU2) transform(m [][]float64, center []float64) {
+	x := make([]float64, s.Dim)
+	for _, point := range s.Points {
+		for i := range x {
+			x[i] = point.Location[i] - center[i]
+		}
+		for i, row := range m {
+			point.Location[i] = center[i] + dot(row, x)
+		}
+	}
+}
+
+// flip reorders the vertices of the element to reverse its orientation. It
+// panics for element types without a known orientation.
+func flip(elem *Element) {
+	order, ok := flipOrders[elem.Type]
+	if !ok || len(order) != len(elem.VertexIds) {
+		panic(fmt.Sprintf("mesh: flip of %v element with %d vertices", elem.Type, len(elem.VertexIds)))
+	}
+	v := make([]PointID, len(elem.VertexIds))
+	for i, local := range order {
+		v[i] = elem.VertexIds[local]
+	}
+	elem.VertexIds = v
+}
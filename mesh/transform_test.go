@@ -0,0 +1,97 @@
+package mesh
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	for _, file := range []string{hybrid2D, hybrid3D} {
+		s := readString(t, file)
+		if s.Dim == 3 {
+			addBoundaryMarker(s, "boundary")
+		}
+		volumes := make([]float64, len(s.Elements))
+		for i, elem := range s.Elements {
+			volumes[i] = s.Volume(elem)
+		}
+		center := make([]float64, s.Dim)
+		center[0] = 1
+		var axis []float64
+		if s.Dim == 3 {
+			axis = []float64{0, 0, 2}
+		}
+		normal := make([]float64, s.Dim)
+		normal[0] = 1
+
+		if err := s.Scale(2); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Translate(center); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Rotate(center, axis, 90); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Mirror(center, normal); err != nil {
+			t.Fatal(err)
+		}
+		// The origin moves to the center and stays there.
+		want := []float64{1, 0, 0}[:s.Dim]
+		if !closeTo(s.Points[0].Location, want, 1e-14) {
+			t.Errorf("%dD: point mismatch. Expected %v, found %v", s.Dim, want, s.Points[0].Location)
+		}
+		// In 2D (0.5, 0) moves to (1, 0), (2, 0) and then (1, 1) on the
+		// mirror. In 3D (1, 0, 0) moves to (2, 0, 0), (3, 0, 0) and (1, 2, 0).
+		want = []float64{1, 1}
+		if s.Dim == 3 {
+			want = []float64{1, 2, 0}
+		}
+		if !closeTo(s.Points[1].Location, want, 1e-14) {
+			t.Errorf("%dD: point mismatch. Expected %v, found %v", s.Dim, want, s.Points[1].Location)
+		}
+		factor := math.Pow(2, float64(s.Dim))
+		for i, elem := range s.Elements {
+			if v := s.Volume(elem); math.Abs(v-factor*volumes[i]) > 1e-13 {
+				t.Errorf("%dD element %d: volume mismatch. Expected %v, found %v", s.Dim, i, factor*volumes[i], v)
+			}
+		}
+		for _, marker := range s.Markers {
+			normals, err := s.MarkerNormals(marker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range marker.Elements {
+				if n := s.Normal(&marker.Elements[i]); !closeTo(n, normals[i], 1e-14) {
+					t.Errorf("%dD marker %s element %d: not outward", s.Dim, marker.Tag, i)
+				}
+			}
+		}
+	}
+
+	s := readString(t, hybrid2D)
+	if err := s.Scale(-1); err == nil {
+		t.Errorf("no error for negative scale")
+	}
+	if err := s.Rotate([]float64{0, 0}, []float64{0, 0, 1}, 90); err == nil {
+		t.Errorf("no error for 2D rotation axis")
+	}
+	s.Elements[0].Type = VTKType(99)
+	x := append([]float64(nil), s.Points[1].Location...)
+	if err := s.Mirror([]float64{0, 0}, []float64{1, 0}); err == nil {
+		t.Errorf("no error for mirroring an unknown element type")
+	}
+	if !closeTo(s.Points[1].Location, x, 0) {
+		t.Errorf("points moved by a failed mirror")
+	}
+
+	s = readString(t, hybrid2D)
+	marker := s.Markers[0]
+	marker.Elements[0].VertexIds = marker.Elements[0].VertexIds[:1]
+	if err := s.Mirror([]float64{0, 0}, []float64{1, 0}); err == nil {
+		t.Errorf("no error for mirroring a truncated marker element")
+	}
+	if !closeTo(s.Points[1].Location, x, 0) {
+		t.Errorf("points moved by a failed mirror")
+	}
+}
@@ -0,0 +1,277 @@
+package mesh
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// IssueKind is a kind of problem found by Validate.
+type IssueKind int
+
+const (
+	// DimensionMismatch is a point or element whose dimension doesn't match
+	// NDIME, or an NDIME other than 2 or 3.
+	DimensionMismatch IssueKind = iota
+	// BadElement is an element of an unsupported type, with the wrong
+	// number of vertices or with a repeated vertex.
+	BadElement
+	// VertexOutOfRange is an element vertex that isn't a point of the mesh.
+	VertexOutOfRange
+	// DuplicatePoint is a point at the same location as an earlier point.
+	DuplicatePoint
+	// UnusedPoint is a point that isn't a vertex of any element.
+	UnusedPoint
+	// InvertedElement is an element with a volume that isn't positive.
+	InvertedElement
+	// UnmatchedMarkerElement is a marker element that isn't a face of any
+	// element.
+	UnmatchedMarkerElement
+	// UncoveredBoundaryFace is a boundary face that isn't in any marker.
+	UncoveredBoundaryFace
+	// DuplicateMarkerTag is a marker with the same tag as an earlier marker.
+	DuplicateMarkerTag
+)
+
+var issueNames = []string{
+	DimensionMismatch:      "dimension mismatch",
+	BadElement:             "bad element",
+	VertexOutOfRange:       "vertex out of range",
+	DuplicatePoint:         "duplicate point",
+	UnusedPoint:            "unused point",
+	InvertedElement:        "inverted element",
+	UnmatchedMarkerElement: "unmatched marker element",
+	UncoveredBoundaryFace:  "uncovered boundary face",
+	DuplicateMarkerTag:     "duplicate marker tag",
+}
+
+func (k IssueKind) String() string {
+	if k < 0 || int(k) >= len(issueNames) {
+		return "unknown issue"
+	}
+	return issueNames[k]
+}
+
+// Issue is a problem found by Validate. Fields that don't apply to the kind
+// of issue are -1 or empty.
+type Issue struct {
+	Kind IssueKind
+	// Element is the element with the issue, or the element owning an
+	// uncovered boundary face.
+	Element ElementID
+	// Marker and MarkerElement are the tag of the marker with the issue and
+	// the index of the element in Marker.Elements.
+	Marker        string
+	MarkerElement int
+	// Points are the points with the issue. For a duplicate point they are
+	// the earlier point and the duplicate, and for faces they are the face
+	// vertices.
+	Points []PointID
+	// Detail describes the issue further.
+	Detail string
+}
+
+func (i Issue) String() string {
+	var b bytes.Buffer
+	b.WriteString(i.Kind.String())
+	if i.Element >= 0 {
+		fmt.Fprintf(&b, ": element %d", i.Element)
+	}
+	if i.Marker != "" {
+		fmt.Fprintf(&b, ": marker %s", i.Marker)
+		if i.MarkerElement >= 0 {
+			fmt.Fprintf(&b, " element %d", i.MarkerElement)
+		}
+	}
+	if len(i.Points) > 0 {
+		fmt.Fprintf(&b, ": points %v", i.Points)
+	}
+	if i.Detail != "" {
+		fmt.Fprintf(&b, ": %s", i.Detail)
+	}
+	return b.String()
+}
+
+// Validate checks the mesh for problems that SU2 doesn't catch when reading
+// it and returns them all. Points closer than tol are duplicates, and a tol
+// of zero is relative to the size of the mesh. Validate also works on a mesh
+// that ReadFrom read but then rejected.
+func (s *SU2) Validate(tol float64) []Issue {
+	v := &validator{s: s}
+	if s.Dim != 2 && s.Dim != 3 {
+		v.add(DimensionMismatch, -1, "", -1, nil, fmt.Sprintf("NDIME= %d", s.Dim))
+		return v.issues
+	}
+	goodPoint := v.points(tol)
+	goodElem := v.elements(goodPoint)
+	v.markers(goodPoint, goodElem)
+	return v.issues
+}
+
+type validator struct {
+	s      *SU2
+	issues []Issue
+}
+
+func (v *validator) add(kind IssueKind, elem ElementID, tag string, markerElem int, points []PointID, detail string) {
+	v.issues = append(v.issues, Issue{
+		Kind:          kind,
+		Element:       elem,
+		Marker:        tag,
+		MarkerElement: markerElem,
+		Points:        points,
+		Detail:        detail,
+	})
+}
+
+// points checks the dimension of the points and finds duplicates. It returns
+// whether each point has the right dimension.
+func (v *validator) points(tol float64) []bool {
+	s := v.s
+	good := make([]bool, len(s.Points))
+	var x [][]float64
+	for i, point := range s.Points {
+		if len(point.Location) != s.Dim {
+			v.add(DimensionMismatch, -1, "", -1, []PointID{PointID(i)},
+				fmt.Sprintf("%d coordinates", len(point.Location)))
+			continue
+		}
+		good[i] = true
+		x = append(x, point.Location)
+	}
+	if tol <= 0 {
+		tol = defaultTol(x)
+	}
+	h := newPointHash(tol)
+	var index []PointID
+	for i, point := range s.Points {
+		if !good[i] {
+			continue
+		}
+		if j := h.find(point.Location); j != -1 {
+			v.add(DuplicatePoint, -1, "", -1, []PointID{index[j], PointID(i)}, "")
+			continue
+		}
+		h.add(point.Location)
+		index = append(index, PointID(i))
+	}
+	return good
+}
+
+// elements checks the type, vertices and volume of the elements and finds
+// the unused points. It returns whether each element can be used to check
+// the markers.
+func (v *validator) elements(goodPoint []bool) []bool {
+	s := v.s
+	good := make([]bool, len(s.Elements))
+	used := make([]bool, len(s.Points))
+	for i, elem := range s.Elements {
+		// The points of bad elements are still used.
+		for _, p := range elem.VertexIds {
+			if p >= 0 && int(p) < len(used) {
+				used[p] = true
+			}
+		}
+		id := ElementID(i)
+		if !v.checkElement(elem, id, "", -1, s.Dim, goodPoint) {
+			continue
+		}
+		good[i] = true
+		if vol := s.Volume(elem); !(vol > 0) {
+			v.add(InvertedElement, id, "", -1, nil, fmt.Sprintf("volume %g", vol))
+		}
+	}
+	for i, u := range used {
+		if !u {
+			v.add(UnusedPoint, -1, "", -1, []PointID{PointID(i)}, "")
+		}
+	}
+	return good
+}
+
+// checkElement checks the type, dimension and vertices of an element or a
+// marker element, and returns whether they are all good.
+func (v *validator) checkElement(elem *Element, id ElementID, tag string, markerElem int, dim int, goodPoint []bool) bool {
+	if !elem.Type.Supported() {
+		v.add(BadElement, id, tag, markerElem, nil, fmt.Sprintf("type %d", elem.Type))
+		return false
+	}
+	if len(elem.VertexIds) != elem.Type.NumNodes() {
+		v.add(BadElement, id, tag, markerElem, elem.VertexIds,
+			fmt.Sprintf("%v with %d vertices", elem.Type, len(elem.VertexIds)))
+		return false
+	}
+	if elem.Type.Dim() != dim {
+		v.add(DimensionMismatch, id, tag, markerElem, nil,
+			fmt.Sprintf("%v in a %dD mesh", elem.Type, v.s.Dim))
+		return false
+	}
+	ok := true
+	for j, p := range elem.VertexIds {
+		if p < 0 || int(p) >= len(v.s.Points) {
+			v.add(VertexOutOfRange, id, tag, markerElem, []PointID{p}, "")
+			ok = false
+			continue
+		}
+		if !goodPoint[p] {
+			ok = false
+		}
+		for _, q := range elem.VertexIds[:j] {
+			if p == q {
+				v.add(BadElement, id, tag, markerElem, elem.VertexIds, fmt.Sprintf("repeated vertex %d", p))
+				ok = false
+				break
+			}
+		}
+	}
+	return ok
+}
+
+// markers checks the marker tags and that the marker elements are the
+// boundary faces of the elements.
+func (v *validator) markers(goodPoint, goodElem []bool) {
+	s := v.s
+	faces := s.facesOf(goodElem)
+
+	tags := make(map[string]bool)
+	marked := make(map[faceKey]bool)
+	for _, marker := range s.Markers {
+		if tags[marker.Tag] {
+			v.add(DuplicateMarkerTag, -1, marker.Tag, -1, nil, "")
+		}
+		tags[marker.Tag] = true
+		for j := range marker.Elements {
+			elem := &marker.Elements[j]
+			if !v.checkElement(elem, -1, marker.Tag, j, s.Dim-1, goodPoint) {
+				continue
+			}
+			key := newFaceKey(elem.VertexIds)
+			marked[key] = true
+			if _, ok := faces[key]; !ok {
+				v.add(UnmatchedMarkerElement, -1, marker.Tag, j, elem.VertexIds, "")
+			}
+		}
+	}
+
+	var uncovered []faceKey
+	for key, refs := range faces {
+		if len(refs) == 1 && !marked[key] {
+			uncovered = append(uncovered, key)
+		}
+	}
+	// Report the faces in element order so the issues are reproducible.
+	sort.Slice(uncovered, func(i, j int) bool {
+		a, b := faces[uncovered[i]][0], faces[uncovered[j]][0]
+		return a.Element < b.Element || a.Element == b.Element && a.Face < b.Face
+	})
+	for _, key := range uncovered {
+		ref := faces[key][0]
+		elem := s.Elements[ref.Element]
+		face := elem.Type.Faces()[ref.Face]
+		points := make([]PointID, len(face))
+		for k, local := range face {
+			points[k] = elem.VertexIds[local]
+		}
+		v.add(UncoveredBoundaryFace, ref.Element, "", -1, points, "")
+	}
+}
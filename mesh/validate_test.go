@@ -0,0 +1,112 @@
+package mesh
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	f, err := os.Open("mesh_flatplate_turb_137x97.su2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := &SU2{SkipNeighbors: true}
+	if _, err := s.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+	if issues := s.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in valid mesh: %v", issues)
+	}
+	s = readString(t, hybrid3D)
+	addBoundaryMarker(s, "boundary")
+	if issues := s.Validate(0); len(issues) != 0 {
+		t.Errorf("issues in valid mesh: %v", issues)
+	}
+
+	for _, test := range []struct {
+		name, old, new string
+		want           []Issue
+	}{
+		{
+			name: "dimension",
+			old:  "NDIME= 2", new: "NDIME= 4",
+			want: []Issue{{Kind: DimensionMismatch, Element: -1, MarkerElement: -1, Detail: "NDIME= 4"}},
+		},
+		{
+			name: "element type",
+			old:  "3	5	4\n", new: "5	5	4	3\n",
+			want: []Issue{
+				{Kind: DimensionMismatch, Element: -1, Marker: "upper", MarkerElement: 0, Detail: "triangle in a 2D mesh"},
+				{Kind: UncoveredBoundaryFace, Element: 2, MarkerElement: -1, Points: []PointID{5, 4}},
+			},
+		},
+		{
+			name: "inverted",
+			old:  "5	1	2	4	1", new: "5	1	4	2	1",
+			want: []Issue{{Kind: InvertedElement, Element: 1, MarkerElement: -1, Detail: "volume -0.25"}},
+		},
+		{
+			name: "duplicate point",
+			old:  "1	1	5\n", new: "0.5	1	5\n",
+			want: []Issue{
+				{Kind: DuplicatePoint, Element: -1, MarkerElement: -1, Points: []PointID{4, 5}},
+				{Kind: InvertedElement, Element: 2, MarkerElement: -1, Detail: "volume 0"},
+			},
+		},
+		{
+			// The points of the bad element are still used.
+			name: "repeated vertex",
+			old:  "5	2	5	4	2", new: "5	2	5	2	2",
+			want: []Issue{
+				{Kind: BadElement, Element: 2, MarkerElement: -1, Points: []PointID{2, 5, 2}, Detail: "repeated vertex 2"},
+				{Kind: UnmatchedMarkerElement, Element: -1, Marker: "sides", MarkerElement: 0, Points: []PointID{2, 5}},
+				{Kind: UnmatchedMarkerElement, Element: -1, Marker: "upper", MarkerElement: 0, Points: []PointID{5, 4}},
+				{Kind: UncoveredBoundaryFace, Element: 1, MarkerElement: -1, Points: []PointID{2, 4}},
+			},
+		},
+		{
+			name: "marker",
+			old:  "3	5	4\n", new: "3	5	1\n",
+			want: []Issue{
+				{Kind: UnmatchedMarkerElement, Element: -1, Marker: "upper", MarkerElement: 0, Points: []PointID{5, 1}},
+				{Kind: UncoveredBoundaryFace, Element: 2, MarkerElement: -1, Points: []PointID{5, 4}},
+			},
+		},
+		{
+			name: "tags",
+			old:  "MARKER_TAG= upper", new: "MARKER_TAG= lower",
+			want: []Issue{{Kind: DuplicateMarkerTag, Element: -1, Marker: "lower", MarkerElement: -1}},
+		},
+	} {
+		// The sides of the square are covered so only the changes are found.
+		file := strings.Replace(hybrid2D, "NMARK= 2", `NMARK= 3
+MARKER_TAG= sides
+MARKER_ELEMS= 2
+3	2	5
+3	3	0`, 1)
+		file = strings.Replace(file, test.old, test.new, 1)
+		s := &SU2{}
+		// Some of the meshes are rejected, but can still be validated.
+		s.ReadFrom(strings.NewReader(file))
+		if issues := s.Validate(0); !reflect.DeepEqual(issues, test.want) {
+			t.Errorf("%s: issue mismatch.\nExpected %v\nFound    %v", test.name, test.want, issues)
+		}
+	}
+
+	s = readString(t, hybrid2D)
+	s.Points = append(s.Points, &Point{Id: 6, Location: []float64{2, 2}})
+	s.Elements[0].VertexIds[0] = 7
+	issues := s.Validate(0)
+	for _, kind := range []IssueKind{VertexOutOfRange, UnusedPoint, UncoveredBoundaryFace} {
+		var found bool
+		for _, issue := range issues {
+			found = found || issue.Kind == kind
+		}
+		if !found {
+			t.Errorf("%v not found in %v", kind, issues)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package mesh
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// VTKArray is a data array attached to the points or cells of a VTK dataset.
+// Data holds Components consecutive values for each point or cell.
+type VTKArray struct {
+	Name       string
+	Components int
+	Data       []float64
+}
+
+// Subset returns the array restricted to the given points or cells, such as
+// the points returned by MarkerMesh.
+func (a VTKArray) Subset(ids []PointID) VTKArray {
+	sub := VTKArray{
+		Name:       a.Name,
+		Components: a.Components,
+		Data:       make([]float64, 0, len(ids)*a.Components),
+	}
+	for _, id := range ids {
+		i := int(id) * a.Components
+		sub.Data = append(sub.Data, a.Data[i:i+a.Components]...)
+	}
+	return sub
+}
+
+// VTKData holds the data arrays written along with a mesh.
+type VTKData struct {
+	PointData []VTKArray
+	CellData  []VTKArray
+}
+
+// VTUEncoding is the encoding of the data arrays in a VTU file.
+type VTUEncoding int
+
+const (
+	VTUASCII  VTUEncoding = iota
+	VTUBinary             // Inline base64 binary data
+)
+
+func (s *SU2) checkVTKData(data *VTKData) error {
+	if data == nil {
+		return nil
+	}
+	for _, a := range data.PointData {
+		if a.Components < 1 || len(a.Data) != a.Components*len(s.Points) {
+			return fmt.Errorf("point data %s: %d values for %d points with %d components", a.Name, len(a.Data), len(s.Points), a.Components)
+		}
+	}
+	for _, a := range data.CellData {
+		if a.Components < 1 || len(a.Data) != a.Components*len(s.Elements) {
+			return fmt.Errorf("cell data %s: %d values for %d cells with %d components", a.Name, len(a.Data), len(s.Elements), a.Components)
+		}
+	}
+	return nil
+}
+
+// WriteVTK writes the mesh and the data arrays, which may be nil, as a VTK
+// legacy ASCII unstructured grid. Points of 2D meshes are written with z = 0.
+// The legacy format ends array names at whitespace, so whitespace in the names
+// is written as underscores.
+func (s *SU2) WriteVTK(w io.Writer, data *VTKData) error {
+	if err := s.checkVTKData(data); err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+	buf.WriteString("# vtk DataFile Version 3.0\n")
+	buf.WriteString("su2tools mesh\n")
+	buf.WriteString("ASCII\n")
+	buf.WriteString("DATASET UNSTRUCTURED_GRID\n")
+
+	fmt.Fprintf(buf, "POINTS %d double\n", len(s.Points))
+	for _, point := range s.Points {
+		for i := 0; i < 3; i++ {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			var v float64
+			if i < len(point.Location) {
+				v = point.Location[i]
+			}
+			buf.WriteString(formatFloat(v))
+		}
+		buf.WriteByte('\n')
+	}
+
+	size := 0
+	for _, elem := range s.Elements {
+		size += len(elem.VertexIds) + 1
+	}
+	fmt.Fprintf(buf, "CELLS %d %d\n", len(s.Elements), size)
+	for _, elem := range s.Elements {
+		buf.WriteString(strconv.Itoa(len(elem.VertexIds)))
+		for _, id := range elem.VertexIds {
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.Itoa(int(id)))
+		}
+		buf.WriteByte('\n')
+	}
+	fmt.Fprintf(buf, "CELL_TYPES %d\n", len(s.Elements))
+	for _, elem := range s.Elements {
+		buf.WriteString(strconv.Itoa(int(elem.Type)) + "\n")
+	}
+
+	if data != nil {
+		writeLegacyArrays(buf, "POINT_DATA", len(s.Points), data.PointData)
+		writeLegacyArrays(buf, "CELL_DATA", len(s.Elements), data.CellData)
+	}
+	return buf.Flush()
+}
+
+func writeLegacyArrays(buf *bufio.Writer, section string, n int, arrays []VTKArray) {
+	if len(arrays) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s %d\n", section, n)
+	fmt.Fprintf(buf, "FIELD FieldData %d\n", len(arrays))
+	for _, a := range arrays {
+		fmt.Fprintf(buf, "%s %d %d double\n", legacyName(a.Name), a.Components, n)
+		for i, v := range a.Data {
+			buf.WriteString(formatFloat(v))
+			if (i+1)%a.Components == 0 {
+				buf.WriteByte('\n')
+			} else {
+				buf.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// legacyName returns the array name with whitespace replaced by underscores.
+func legacyName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// WriteVTU writes the mesh and the data arrays, which may be nil, as a VTK XML
+// unstructured grid. Points of 2D meshes are written with z = 0.
+func (s *SU2) WriteVTU(w io.Writer, data *VTKData, enc VTUEncoding) error {
+	if err := s.checkVTKData(data); err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(w)
+	buf.WriteString("<?xml version=\"1.0\"?>\n")
+	buf.WriteString("<VTKFile type=\"UnstructuredGrid\" version=\"0.1\" byte_order=\"LittleEndian\" header_type=\"UInt32\">\n")
+	buf.WriteString("<UnstructuredGrid>\n")
+	fmt.Fprintf(buf, "<Piece NumberOfPoints=\"%d\" NumberOfCells=\"%d\">\n", len(s.Points), len(s.Elements))
+
+	points := make([]float64, 3*len(s.Points))
+	for i, point := range s.Points {
+		copy(points[3*i:3*i+3], point.Location)
+	}
+	buf.WriteString("<Points>\n")
+	writeFloatArray(buf, "", 3, points, enc)
+	buf.WriteString("</Points>\n")
+
+	var connectivity, offsets []int64
+	types := make([]uint8, len(s.Elements))
+	for i, elem := range s.Elements {
+		for _, id := range elem.VertexIds {
+			connectivity = append(connectivity, int64(id))
+		}
+		offsets = append(offsets, int64(len(connectivity)))
+		types[i] = uint8(elem.Type)
+	}
+	buf.WriteString("<Cells>\n")
+	writeIntArray(buf, "connectivity", connectivity, enc)
+	writeIntArray(buf, "offsets", offsets, enc)
+	writeTypeArray(buf, "types", types, enc)
+	buf.WriteString("</Cells>\n")
+
+	if data != nil {
+		buf.WriteString("<PointData>\n")
+		for _, a := range data.PointData {
+			writeFloatArray(buf, a.Name, a.Components, a.Data, enc)
+		}
+		buf.WriteString("</PointData>\n")
+		buf.WriteString("<CellData>\n")
+		for _, a := range data.CellData {
+			writeFloatArray(buf, a.Name, a.Components, a.Data, enc)
+		}
+		buf.WriteString("</CellData>\n")
+	}
+
+	buf.WriteString("</Piece>\n")
+	buf.WriteString("</UnstructuredGrid>\n")
+	buf.WriteString("</VTKFile>\n")
+	return buf.Flush()
+}
+
+// WriteVTUMarkers writes each marker as a separate VTU boundary dataset to the
+// writer returned by open for its tag. The writer is closed after the marker is
+// written. Point data arrays are restricted to the points of each marker.
+func (s *SU2) WriteVTUMarkers(open func(tag string) (io.WriteCloser, error), pointData []VTKArray, enc VTUEncoding) error {
+	return s.writeMarkers(open, pointData, func(boundary *SU2, w io.Writer, data *VTKData) error {
+		return boundary.WriteVTU(w, data, enc)
+	})
+}
+
+// WriteVTKMarkers is like WriteVTUMarkers, but writes VTK legacy files.
+func (s *SU2) WriteVTKMarkers(open func(tag string) (io.WriteCloser, error), pointData []VTKArray) error {
+	return s.writeMarkers(open, pointData, func(boundary *SU2, w io.Writer, data *VTKData) error {
+		return boundary.WriteVTK(w, data)
+	})
+}
+
+// writeMarkers writes each marker to the writer returned by open using write.
+func (s *SU2) writeMarkers(open func(tag string) (io.WriteCloser, error), pointData []VTKArray, write func(boundary *SU2, w io.Writer, data *VTKData) error) error {
+	for _, marker := range s.Markers {
+		boundary, ids, err := s.MarkerMesh(marker.Tag)
+		if err != nil {
+			return err
+		}
+		data := &VTKData{}
+		for _, a := range pointData {
+			data.PointData = append(data.PointData, a.Subset(ids))
+		}
+		w, err := open(marker.Tag)
+		if err != nil {
+			return err
+		}
+		err = write(boundary, w, data)
+		cerr := w.Close()
+		if err != nil {
+			return err
+		}
+		if cerr != nil {
+			return cerr
+		}
+	}
+	return nil
+}
+
+// MarkerMesh returns the elements of a marker as a surface mesh with its own
+// point numbering. The ids of the original points are returned in the order of
+// the new points.
+func (s *SU2) MarkerMesh(tag string) (*SU2, []PointID, error) {
+	marker := s.Marker(tag)
+	if marker == nil {
+		return nil, nil, fmt.Errorf("no marker %s", tag)
+	}
+	ids := marker.PointIds()
+	index := make(map[PointID]PointID, len(ids))
+	boundary := &SU2{
+		Dim:           s.Dim,
+		Points:        make([]*Point, len(ids)),
+		Elements:      make([]*Element, len(marker.Elements)),
+		SkipNeighbors: true,
+	}
+	for i, id := range ids {
+		index[id] = PointID(i)
+		boundary.Points[i] = &Point{
+			Id:       PointID(i),
+			Location: append([]float64(nil), s.Points[id].Location...),
+		}
+	}
+	for i, elem := range marker.Elements {
+		vertices := make([]PointID, len(elem.VertexIds))
+		for j, id := range elem.VertexIds {
+			vertices[j] = index[id]
+		}
+		boundary.Elements[i] = &Element{
+			Id:        ElementID(i),
+			Type:      elem.Type,
+			VertexIds: vertices,
+		}
+	}
+	return boundary, ids, nil
+}
+
+func writeFloatArray(buf *bufio.Writer, name string, comps int, data []float64, enc VTUEncoding) {
+	openDataArray(buf, "Float64", name, comps, enc)
+	if enc == VTUBinary {
+		b := make([]byte, 8*len(data))
+		for i, v := range data {
+			binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(v))
+		}
+		writeBase64(buf, b)
+	} else {
+		for i, v := range data {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(formatFloat(v))
+		}
+	}
+	buf.WriteString("\n</DataArray>\n")
+}
+
+func writeIntArray(buf *bufio.Writer, name string, data []int64, enc VTUEncoding) {
+	openDataArray(buf, "Int64", name, 1, enc)
+	if enc == VTUBinary {
+		b := make([]byte, 8*len(data))
+		for i, v := range data {
+			binary.LittleEndian.PutUint64(b[8*i:], uint64(v))
+		}
+		writeBase64(buf, b)
+	} else {
+		for i, v := range data {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(strconv.FormatInt(v, 10))
+		}
+	}
+	buf.WriteString("\n</DataArray>\n")
+}
+
+func writeTypeArray(buf *bufio.Writer, name string, data []uint8, enc VTUEncoding) {
+	openDataArray(buf, "UInt8", name, 1, enc)
+	if enc == VTUBinary {
+		writeBase64(buf, data)
+	} else {
+		for i, v := range data {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(strconv.Itoa(int(v)))
+		}
+	}
+	buf.WriteString("\n</DataArray>\n")
+}
+
+func openDataArray(buf *bufio.Writer, typ, name string, comps int, enc VTUEncoding) {
+	format := "ascii"
+	if enc == VTUBinary {
+		format = "binary"
+	}
+	fmt.Fprintf(buf, "<DataArray type=\"%s\"", typ)
+	if name != "" {
+		fmt.Fprintf(buf, " Name=\"%s\"", html.EscapeString(name))
+	}
+	if comps > 1 {
+		fmt.Fprintf(buf, " NumberOfComponents=\"%d\"", comps)
+	}
+	fmt.Fprintf(buf, " format=\"%s\">\n", format)
+}
+
+// writeBase64 writes the data as inline binary VTU data, which is base64
+// encoded and prefixed by the number of bytes.
+func writeBase64(buf *bufio.Writer, data []byte) {
+	b := make([]byte, 4+len(data))
+	binary.LittleEndian.PutUint32(b, uint32(len(data)))
+	copy(b[4:], data)
+	buf.WriteString(base64.StdEncoding.EncodeToString(b))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
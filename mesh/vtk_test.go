@@ -0,0 +1,153 @@
+package mesh
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type vtuFile struct {
+	Piece struct {
+		NumberOfPoints int `xml:",attr"`
+		NumberOfCells  int `xml:",attr"`
+		Points         struct {
+			DataArray vtuArray
+		}
+		Cells struct {
+			DataArray []vtuArray
+		}
+		PointData struct {
+			DataArray []vtuArray
+		}
+	} `xml:"UnstructuredGrid>Piece"`
+}
+
+type vtuArray struct {
+	Name   string `xml:",attr"`
+	Format string `xml:"format,attr"`
+	Text   string `xml:",chardata"`
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestWriteVTU(t *testing.T) {
+	s := readString(t, hybrid2D)
+	data := &VTKData{
+		PointData: []VTKArray{{Name: "id", Components: 1, Data: []float64{0, 1, 2, 3, 4, 5}}},
+		CellData:  []VTKArray{{Name: "volume", Components: 1, Data: []float64{0.5, 0.25, 0.25}}},
+	}
+	for _, enc := range []VTUEncoding{VTUASCII, VTUBinary} {
+		var buf bytes.Buffer
+		if err := s.WriteVTU(&buf, data, enc); err != nil {
+			t.Fatal(err)
+		}
+		var file vtuFile
+		if err := xml.Unmarshal(buf.Bytes(), &file); err != nil {
+			t.Fatalf("bad xml: %v", err)
+		}
+		if file.Piece.NumberOfPoints != 6 || file.Piece.NumberOfCells != 3 {
+			t.Errorf("Size mismatch. Found %d points and %d cells", file.Piece.NumberOfPoints, file.Piece.NumberOfCells)
+		}
+		points := file.Piece.Points.DataArray
+		var x []float64
+		if enc == VTUBinary {
+			b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(points.Text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := binary.LittleEndian.Uint32(b); int(n) != len(b)-4 {
+				t.Errorf("Header mismatch. Expected %v, found %v", len(b)-4, n)
+			}
+			for i := 4; i < len(b); i += 8 {
+				x = append(x, math.Float64frombits(binary.LittleEndian.Uint64(b[i:])))
+			}
+		} else {
+			for _, str := range strings.Fields(points.Text) {
+				v, _ := strconv.ParseFloat(str, 64)
+				x = append(x, v)
+			}
+		}
+		if !closeTo(x[12:15], []float64{0.5, 1, 0}, 0) {
+			t.Errorf("Point mismatch. Expected %v, found %v", []float64{0.5, 1, 0}, x[12:15])
+		}
+		if len(file.Piece.PointData.DataArray) != 1 || file.Piece.PointData.DataArray[0].Name != "id" {
+			t.Errorf("point data not written")
+		}
+	}
+
+	data.PointData[0].Data = data.PointData[0].Data[1:]
+	if err := s.WriteVTU(io.Discard, data, VTUASCII); err == nil {
+		t.Errorf("no error for point data of the wrong size")
+	}
+
+	files := make(map[string]*bytes.Buffer)
+	err := s.WriteVTUMarkers(func(tag string) (io.WriteCloser, error) {
+		files[tag] = &bytes.Buffer{}
+		return nopCloser{files[tag]}, nil
+	}, nil, VTUASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file vtuFile
+	if err := xml.Unmarshal(files["upper"].Bytes(), &file); err != nil {
+		t.Fatalf("bad xml: %v", err)
+	}
+	if file.Piece.NumberOfPoints != 3 || file.Piece.NumberOfCells != 2 {
+		t.Errorf("Marker size mismatch. Found %d points and %d cells", file.Piece.NumberOfPoints, file.Piece.NumberOfCells)
+	}
+}
+
+func TestWriteVTK(t *testing.T) {
+	s := readString(t, hybrid3D)
+	var buf bytes.Buffer
+	data := &VTKData{
+		PointData: []VTKArray{
+			{Name: "velocity", Components: 3, Data: make([]float64, 36)},
+			{Name: "Mach number", Components: 1, Data: make([]float64, 12)},
+		},
+	}
+	if err := s.WriteVTK(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"DATASET UNSTRUCTURED_GRID",
+		"POINTS 12 double",
+		"CELLS 4 27",
+		"CELL_TYPES 4",
+		"POINT_DATA 12",
+		"velocity 3 12 double",
+		"Mach_number 1 12 double",
+	} {
+		if !strings.Contains(buf.String(), "\n"+line+"\n") {
+			t.Errorf("missing line %q", line)
+		}
+	}
+
+	s = readString(t, hybrid2D)
+	files := make(map[string]*bytes.Buffer)
+	err := s.WriteVTKMarkers(func(tag string) (io.WriteCloser, error) {
+		files[tag] = &bytes.Buffer{}
+		return nopCloser{files[tag]}, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(s.Markers) {
+		t.Errorf("Marker file mismatch. Expected %d, found %d", len(s.Markers), len(files))
+	}
+	for _, line := range []string{"POINTS 3 double", "CELLS 2 6", "CELL_TYPES 2"} {
+		if !strings.Contains(files["upper"].String(), "\n"+line+"\n") {
+			t.Errorf("marker missing line %q", line)
+		}
+	}
+}
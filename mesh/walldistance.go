@@ -0,0 +1,152 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// wallChunk is the number of points each worker takes at a time.
+const wallChunk = 1024
+
+// WallDistance returns the distance from every point to the nearest element
+// of the markers with the given tags, for example the tags of MarkerHeatflux,
+// MarkerIsothermal and MarkerEuler in a config. The distance is to the
+// surface of the wall elements and not only to their points. Quadrilateral
+// wall elements are split into two triangles. The points are divided among
+// GOMAXPROCS goroutines.
+func (s *SU2) WallDistance(tags []string) ([]float64, error) {
+	var walls [][][]float64
+	for _, tag := range tags {
+		marker := s.Marker(tag)
+		if marker == nil {
+			return nil, fmt.Errorf("wall distance: no marker %q", tag)
+		}
+		for i := range marker.Elements {
+			elem := &marker.Elements[i]
+			x := s.coords(elem)
+			switch elem.Type {
+			case Line, Triangle:
+				walls = append(walls, x)
+			case Quadrilateral:
+				walls = append(walls, [][]float64{x[0], x[1], x[2]}, [][]float64{x[0], x[2], x[3]})
+			default:
+				return nil, fmt.Errorf("wall distance: marker %q has %v element", tag, elem.Type)
+			}
+		}
+	}
+	if len(walls) == 0 {
+		return nil, errors.New("wall distance: no wall elements")
+	}
+
+	lo := make([][]float64, len(walls))
+	hi := make([][]float64, len(walls))
+	for i, x := range walls {
+		lo[i], hi[i] = bounds(x)
+	}
+	tree := newBoxTree(lo, hi)
+
+	dist := make([]float64, len(s.Points))
+	chunks := make(chan int)
+	w := &sync.WaitGroup{}
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		w.Add(1)
+		go func() {
+			for start := range chunks {
+				end := start + wallChunk
+				if end > len(dist) {
+					end = len(dist)
+				}
+				for p := start; p < end; p++ {
+					x := s.Points[p].Location
+					_, dist[p] = tree.nearest(x, func(item int) float64 {
+						return simplexDistance(x, walls[item])
+					})
+				}
+			}
+			w.Done()
+		}()
+	}
+	for start := 0; start < len(dist); start += wallChunk {
+		chunks <- start
+	}
+	close(chunks)
+	w.Wait()
+	return dist, nil
+}
+
+// simplexDistance returns the distance from x to the segment or triangle with
+// vertices v.
+func simplexDistance(x []float64, v [][]float64) float64 {
+	if len(v) == 2 {
+		return distance(x, closestOnSegment(x, v[0], v[1]))
+	}
+	return distance(x, closestOnTriangle(x, v[0], v[1], v[2]))
+}
+
+// closestOnSegment returns the point of the segment ab closest to x.
+func closestOnSegment(x, a, b []float64) []float64 {
+	ab := sub(b, a)
+	t := 0.0
+	if l2 := dot(ab, ab); l2 > 0 {
+		t = math.Max(0, math.Min(1, dot(sub(x, a), ab)/l2))
+	}
+	c := make([]float64, len(a))
+	for i := range c {
+		c[i] = a[i] + t*ab[i]
+	}
+	return c
+}
+
+// closestOnTriangle returns the point of the triangle abc closest to x, by
+// finding the Voronoi region of the triangle that contains x. See Ericson,
+// Real-Time Collision Detection, section 5.1.5.
+func closestOnTriangle(x, a, b, c []float64) []float64 {
+	ab, ac, ax := sub(b, a), sub(c, a), sub(x, a)
+	d1, d2 := dot(ab, ax), dot(ac, ax)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bx := sub(x, b)
+	d3, d4 := dot(ab, bx), dot(ac, bx)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return closestOnSegment(x, a, b)
+	}
+	cx := sub(x, c)
+	d5, d6 := dot(ab, cx), dot(ac, cx)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return closestOnSegment(x, a, c)
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return closestOnSegment(x, b, c)
+	}
+	// x projects inside the face.
+	denom := va + vb + vc
+	if denom == 0 {
+		// The triangle is degenerate, so it is covered by its edges.
+		best := closestOnSegment(x, a, b)
+		for _, e := range [][2][]float64{{b, c}, {a, c}} {
+			if q := closestOnSegment(x, e[0], e[1]); distance(x, q) < distance(x, best) {
+				best = q
+			}
+		}
+		return best
+	}
+	v, w := vb/denom, vc/denom
+	p := make([]float64, len(a))
+	for i := range p {
+		p[i] = a[i] + ab[i]*v + ac[i]*w
+	}
+	return p
+}
@@ -0,0 +1,77 @@
+package mesh
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestWallDistance(t *testing.T) {
+	f, err := os.Open("mesh_flatplate_turb_137x97.su2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := &SU2{SkipNeighbors: true}
+	if _, err := s.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+	dist, err := s.WallDistance([]string{"wall"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The plate lies on y = 0 between x0 and x1.
+	x0, x1 := math.Inf(1), math.Inf(-1)
+	for _, id := range s.Marker("wall").PointIds() {
+		x := s.Points[id].Location[0]
+		x0, x1 = math.Min(x0, x), math.Max(x1, x)
+	}
+	for i, point := range s.Points {
+		x, y := point.Location[0], point.Location[1]
+		want := math.Abs(y)
+		if x < x0 {
+			want = math.Hypot(x-x0, y)
+		} else if x > x1 {
+			want = math.Hypot(x-x1, y)
+		}
+		if math.Abs(dist[i]-want) > 1e-14 {
+			t.Errorf("2D point %d: distance mismatch. Expected %v, found %v", i, want, dist[i])
+		}
+	}
+
+	// A cube of 5x5x5 points with the wall on the middle of its bottom face
+	b := &block{ni: 5, nj: 5, nk: 5}
+	for k := 0; k < b.nk; k++ {
+		for j := 0; j < b.nj; j++ {
+			for i := 0; i < b.ni; i++ {
+				b.x = append(b.x, []float64{float64(i), float64(j), float64(k)})
+			}
+		}
+	}
+	patch := Patch{Face: KMin, Tag: "wall", Range: [2][2]int{{1, 3}, {1, 3}}}
+	s, err = structuredMesh([]*block{b}, []Patch{patch}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dist, err = s.WallDistance([]string{"wall"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, point := range s.Points {
+		d := make([]float64, 3)
+		for k, v := range point.Location[:2] {
+			d[k] = math.Max(0, math.Max(1-v, v-3))
+		}
+		d[2] = point.Location[2]
+		if want := norm(d); math.Abs(dist[i]-want) > 1e-14 {
+			t.Errorf("3D point %d: distance mismatch. Expected %v, found %v", i, want, dist[i])
+		}
+	}
+
+	if _, err := s.WallDistance([]string{"airfoil"}); err == nil {
+		t.Errorf("no error for missing marker")
+	}
+	if _, err := s.WallDistance(nil); err == nil {
+		t.Errorf("no error for no walls")
+	}
+}
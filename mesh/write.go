@@ -0,0 +1,64 @@
+package mesh
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// countWriter counts the number of bytes written to the underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo writes the SU2 mesh to an io.Writer in the format accepted by
+// ReadFrom.
+func (s *SU2) WriteTo(w io.Writer) (n int64, err error) {
+	cw := &countWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	// bufio.Writer keeps the first error, so it is only checked on Flush.
+	buf.WriteString("NDIME= " + strconv.Itoa(s.Dim) + "\n")
+
+	buf.WriteString("NELEM= " + strconv.Itoa(len(s.Elements)) + "\n")
+	for i, elem := range s.Elements {
+		writeElement(buf, elem)
+		buf.WriteString("\t" + strconv.Itoa(i) + "\n")
+	}
+
+	buf.WriteString("NPOIN= " + strconv.Itoa(len(s.Points)) + "\n")
+	for i, point := range s.Points {
+		for _, v := range point.Location {
+			buf.WriteString(strconv.FormatFloat(v, 'e', 16, 64))
+			buf.WriteByte('\t')
+		}
+		buf.WriteString(strconv.Itoa(i) + "\n")
+	}
+
+	buf.WriteString("NMARK= " + strconv.Itoa(len(s.Markers)) + "\n")
+	for _, marker := range s.Markers {
+		buf.WriteString("MARKER_TAG= " + marker.Tag + "\n")
+		buf.WriteString("MARKER_ELEMS= " + strconv.Itoa(len(marker.Elements)) + "\n")
+		for i := range marker.Elements {
+			writeElement(buf, &marker.Elements[i])
+			buf.WriteByte('\n')
+		}
+	}
+	err = buf.Flush()
+	return cw.n, err
+}
+
+// writeElement writes the type and the vertices of the element.
+func writeElement(buf *bufio.Writer, elem *Element) {
+	buf.WriteString(strconv.Itoa(int(elem.Type)))
+	for _, id := range elem.VertexIds {
+		buf.WriteByte('\t')
+		buf.WriteString(strconv.Itoa(int(id)))
+	}
+}